@@ -0,0 +1,126 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"sync"
+)
+
+// ReaderAt wraps another io.ReaderAt and maintains a given bandwidth across
+// all concurrent ReadAt calls.
+type ReaderAt struct {
+	mu  sync.Mutex // serializes access to lim among concurrent calls
+	lim *limiter
+	src io.ReaderAt
+}
+
+// NewReaderAt returns a new ReaderAt that wraps ra and maintains the given
+// bandwidth. If bandwidth is zero or negative, the ReaderAt will not limit.
+func NewReaderAt(ra io.ReaderAt, bandwidth int, opts ...Option) *ReaderAt {
+	return &ReaderAt{
+		src: ra,
+		lim: newLimiter(float64(bandwidth), opts),
+	}
+}
+
+// ReadAt implements the io.ReaderAt interface and maintains the given
+// bandwidth. It is safe to call ReadAt concurrently, if it is safe to do so
+// on the wrapped io.ReaderAt; concurrent calls share the bandwidth.
+func (r *ReaderAt) ReadAt(p []byte, off int64) (n int, err error) {
+	if err := r.lim.enter(); err != nil {
+		return 0, err
+	}
+	defer r.lim.leave()
+
+	r.mu.Lock()
+	err = r.lim.begin()
+	r.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = r.src.ReadAt(p, off)
+	if err != nil {
+		return n, err
+	}
+
+	// A RateLimiter, e.g. a Pool, is safe for concurrent use, so that
+	// concurrent calls wait for their tokens in parallel.
+	if r.lim.rateLimiter != nil {
+		_, err = r.lim.limit(n, len(p))
+		return n, err
+	}
+
+	r.mu.Lock()
+	_, err = r.lim.limit(n, len(p))
+	r.mu.Unlock()
+
+	return n, err
+}
+
+// WriterAt wraps another io.WriterAt and maintains a given bandwidth across
+// all concurrent WriteAt calls.
+type WriterAt struct {
+	mu  sync.Mutex // serializes access to lim among concurrent calls
+	lim *limiter
+	dst io.WriterAt
+}
+
+// NewWriterAt returns a new WriterAt that wraps wa and maintains the given
+// bandwidth. If bandwidth is zero or negative, the WriterAt will not limit.
+func NewWriterAt(wa io.WriterAt, bandwidth int, opts ...Option) *WriterAt {
+	return &WriterAt{
+		dst: wa,
+		lim: newLimiter(float64(bandwidth), opts),
+	}
+}
+
+// WriteAt implements the io.WriterAt interface and maintains the given
+// bandwidth. It is safe to call WriteAt concurrently, if it is safe to do so
+// on the wrapped io.WriterAt; concurrent calls share the bandwidth.
+func (w *WriterAt) WriteAt(p []byte, off int64) (n int, err error) {
+	if err := w.lim.enter(); err != nil {
+		return 0, err
+	}
+	defer w.lim.leave()
+
+	w.mu.Lock()
+	err = w.lim.begin()
+	w.mu.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	n, err = w.dst.WriteAt(p, off)
+	if err != nil {
+		return n, err
+	}
+
+	// A RateLimiter, e.g. a Pool, is safe for concurrent use, so that
+	// concurrent calls wait for their tokens in parallel.
+	if w.lim.rateLimiter != nil {
+		_, err = w.lim.limit(n, len(p))
+		return n, err
+	}
+
+	w.mu.Lock()
+	_, err = w.lim.limit(n, len(p))
+	w.mu.Unlock()
+
+	return n, err
+}
@@ -0,0 +1,116 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memWriterAt struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (w *memWriterAt) WriteAt(p []byte, off int64) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return copy(w.buf[off:], p), nil
+}
+
+// parallelChunks calls fn for each chunk of size chunkSize in [0, size)
+// from its own goroutine and returns the total duration.
+func parallelChunks(t *testing.T, size, chunkSize int, fn func(off int64, p []byte) (int, error)) time.Duration {
+	t.Helper()
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for off := 0; off < size; off += chunkSize {
+		wg.Add(1)
+		go func(off int) {
+			defer wg.Done()
+			p := make([]byte, chunkSize)
+			n, err := fn(int64(off), p)
+			if err != nil && err != io.EOF {
+				t.Error(err)
+			}
+			if n != chunkSize {
+				t.Errorf("Want %d bytes, got %d.", chunkSize, n)
+			}
+		}(off)
+	}
+	wg.Wait()
+	return time.Since(start)
+}
+
+func TestReaderAt(t *testing.T) {
+	t.Parallel()
+
+	ra := NewReaderAt(bytes.NewReader(make([]byte, 2000)), 10000)
+	dur := parallelChunks(t, 2000, 250, func(off int64, p []byte) (int, error) {
+		return ra.ReadAt(p, off)
+	})
+	t.Logf("Read 2000 bytes in %s.", dur)
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestWriterAt(t *testing.T) {
+	t.Parallel()
+
+	wa := NewWriterAt(&memWriterAt{buf: make([]byte, 2000)}, 10000)
+	dur := parallelChunks(t, 2000, 250, func(off int64, p []byte) (int, error) {
+		return wa.WriteAt(p, off)
+	})
+	t.Logf("Wrote 2000 bytes in %s.", dur)
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestReaderAtPool(t *testing.T) {
+	t.Parallel()
+
+	// Two ReaderAts draw 2000 bytes in total from a Pool of 10000 B/s.
+	p := NewPool(10000)
+	src := bytes.NewReader(make([]byte, 1000))
+	ras := []*ReaderAt{
+		NewReaderAt(src, 0, WithXRateLimiter(p)),
+		NewReaderAt(src, 0, WithXRateLimiter(p)),
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, ra := range ras {
+		wg.Add(1)
+		go func(ra *ReaderAt) {
+			defer wg.Done()
+			parallelChunks(t, 1000, 250, func(off int64, p []byte) (int, error) {
+				return ra.ReadAt(p, off)
+			})
+		}(ra)
+	}
+	wg.Wait()
+
+	if dur := time.Since(start); dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// zeroReader is an endless source like /dev/zero, except that it does not
+// even bother to zero the buffer.
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	return len(p), nil
+}
+
+var benchCases = []struct {
+	bandwidth int
+	bufSize   int
+}{
+	{0, 1 << 10},
+	{0, 16 << 10},
+	{0, 1 << 20},
+	{10 * MBps, 1 << 10},
+	{10 * MBps, 16 << 10},
+	{10 * MBps, 1 << 20},
+	{100 * MBps, 1 << 10},
+	{100 * MBps, 16 << 10},
+	{100 * MBps, 1 << 20},
+}
+
+func benchName(bandwidth, bufSize int) string {
+	return fmt.Sprintf("bw=%dMBps/buf=%dKiB", bandwidth/MBps, bufSize>>10)
+}
+
+func BenchmarkReader(b *testing.B) {
+	for _, bc := range benchCases {
+		b.Run(benchName(bc.bandwidth, bc.bufSize), func(b *testing.B) {
+			r := NewReader(zeroReader{}, bc.bandwidth)
+			p := make([]byte, bc.bufSize)
+			b.SetBytes(int64(bc.bufSize))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Read(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkWriter(b *testing.B) {
+	for _, bc := range benchCases {
+		b.Run(benchName(bc.bandwidth, bc.bufSize), func(b *testing.B) {
+			w := NewWriter(ioutil.Discard, bc.bandwidth)
+			p := make([]byte, bc.bufSize)
+			b.SetBytes(int64(bc.bufSize))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := w.Write(p); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	const size = 1 << 20
+
+	for _, bc := range benchCases {
+		b.Run(benchName(bc.bandwidth, bc.bufSize), func(b *testing.B) {
+			b.SetBytes(size)
+			for i := 0; i < b.N; i++ {
+				src := io.LimitReader(zeroReader{}, size)
+				if _, err := CopyBufferSize(ioutil.Discard, src, bc.bandwidth, bc.bufSize); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+// BenchmarkLongRunning transfers data at 1 MBps for b.N seconds, i.e. each
+// iteration transfers one second worth of data through the same Reader, and
+// reports the achieved rate relative to the bandwidth. Run it with e.g.
+// -benchtime=60x to catch drift that short tests miss.
+func BenchmarkLongRunning(b *testing.B) {
+	const bandwidth = MBps
+
+	r := NewReader(zeroReader{}, bandwidth)
+	p := make([]byte, 16<<10)
+	b.SetBytes(bandwidth)
+	b.ResetTimer()
+
+	start := time.Now()
+	for i := 0; i < b.N; i++ {
+		for n := 0; n < bandwidth; n += len(p) {
+			if _, err := r.Read(p); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+	dur := time.Since(start)
+
+	rate := float64(b.N) * bandwidth / dur.Seconds()
+	b.ReportMetric(rate/bandwidth, "accuracy")
+}
+
+// mutexBandwidth and rwMutexBandwidth guard the bandwidth with a lock, as
+// opposed to the atomic access of limiter. They only serve as a baseline for
+// BenchmarkBandwidthAccess.
+type mutexBandwidth struct {
+	mu        sync.Mutex
+	bandwidth float64
+}
+
+func (m *mutexBandwidth) get() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.bandwidth
+}
+
+func (m *mutexBandwidth) set(bandwidth float64) {
+	m.mu.Lock()
+	m.bandwidth = bandwidth
+	m.mu.Unlock()
+}
+
+type rwMutexBandwidth struct {
+	mu        sync.RWMutex
+	bandwidth float64
+}
+
+func (m *rwMutexBandwidth) get() float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.bandwidth
+}
+
+func (m *rwMutexBandwidth) set(bandwidth float64) {
+	m.mu.Lock()
+	m.bandwidth = bandwidth
+	m.mu.Unlock()
+}
+
+// BenchmarkBandwidthAccess compares the atomic bandwidth of limiter with
+// mutex and RWMutex guarded alternatives under a read-heavy workload: every
+// goroutine reads the bandwidth and sets it on every 100th iteration.
+func BenchmarkBandwidthAccess(b *testing.B) {
+	l := newLimiter(10*MBps, nil)
+	var m mutexBandwidth
+	var rw rwMutexBandwidth
+
+	benchs := []struct {
+		name string
+		get  func() float64
+		set  func(float64)
+	}{
+		{"atomic", l.getBandwidth, l.setBandwidth},
+		{"mutex", m.get, m.set},
+		{"rwmutex", rw.get, rw.set},
+	}
+
+	for _, bench := range benchs {
+		bench := bench
+		b.Run(bench.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				var sum float64
+				for i := 0; pb.Next(); i++ {
+					if i%100 == 0 {
+						bench.set(10 * MBps)
+					}
+					sum += bench.get()
+				}
+				if math.IsNaN(sum) {
+					b.Fatal("NaN bandwidth")
+				}
+			})
+		})
+	}
+}
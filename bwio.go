@@ -19,7 +19,10 @@
 // uses an internal time bucket and hibernates each io operation for short time
 // periods, whenever the configured bandwidth has been exceeded.
 //
-// `bandwidth` is defined as bytes per second.
+// `bandwidth` is defined as bytes per second, not bits per second as used by
+// networking tools. The constants KBps, MBps and GBps and the helper
+// BitsPerSecond express it in more natural units; NewReaderBps and
+// NewWriterBps take bits per second directly.
 //
 // The limiter tries to detect longer stalls and resets the bucket such that
 // stalls do not cause subsequent high bursts. Usually you should choose small
@@ -29,113 +32,908 @@
 package bwio
 
 import (
+	"context"
+	"errors"
 	"io"
+	"math"
+	"math/rand"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unicode/utf8"
 )
 
+// defaultStallThreshold is the base duration after which the limiter
+// considers the stream stalled.
+const defaultStallThreshold = time.Second
+
+// ErrBackpressureLimitExceeded is returned by Read and Write instead of
+// sleeping, if the penalty exceeds the threshold configured with
+// WithBackpressureClose.
+var ErrBackpressureLimitExceeded = errors.New("bwio: backpressure limit exceeded")
+
+// maxBackoffFactor caps the multiplier of WithExponentialBackoff.
+const maxBackoffFactor = 8
+
+// defaultBufSize is the buffer size of Copy and CopyBuffer.
+const defaultBufSize = 16 << 10
+
 type limiter struct {
-	bandwidth     int
-	start         time.Time
-	bucket        int64
-	isInitialized bool
+	// bandwidth, lastUse and the stats are accessed atomically and must
+	// stay the first fields to be 64-bit aligned on 32-bit platforms.
+	bandwidth      uint64 // float64 bits
+	lastUse        int64  // unix nanos
+	sleptRequested int64  // nanos
+	sleptActual    int64  // nanos
+	transferred    int64  // bytes
+	stalls         int64  // count
+	firstUse       int64  // unix nanos
+	flushes        int64  // count
+
+	maxBandwidth int
+
+	now            func() time.Time
+	ctx            context.Context
+	sleeper        sleeper
+	start          time.Time
+	bucket         int64
+	isInitialized  bool
+	bufSizeHint    int
+	stallThreshold time.Duration
+	onReset        func(reason string)
+	slack          float64
+	minSleep       time.Duration
+	leaky          bool
+	drained        time.Time
+	opLogger       func(op string, n int, penalty time.Duration, ts time.Time)
+	minInterval    time.Duration
+	backpressure   time.Duration
+	burstFactor    float64
+	burstDuration  time.Duration
+	burstStart     time.Time
+	expBackoff     bool
+	backoffFactor  time.Duration
+	jitter         float64
+	bucketCeiling  int64
+	initialCredit  int64
+	isoBytes       int64
+	isoPeriod      time.Duration
+	isoStart       time.Time
+	isoUsed        int64
+	lastBegin      time.Time
+	latency        time.Duration
+	packetLatency  time.Duration
+	syscallCost    time.Duration
+	flusher        flusher
+	peak           peakMeter
+	strict         bool
+	initialDebt    time.Duration
+	cpuTime        bool
+
+	totalSize            int64
+	deadlineProportional bool
+
+	window    time.Duration
+	events    []windowEvent // oldest first
+	windowSum int64
+
+	global *GlobalLimiter
+	sem    *Semaphore
+	pool   *Pool
+
+	rateLimiter RateLimiter
+	pacer       Pacer
+
+	activeMu sync.Mutex    // guards active and idle
+	active   int           // number of operations in progress
+	idle     chan struct{} // closed once active drops to zero
+}
+
+func newLimiter(bandwidth float64, opts []Option) *limiter {
+	l := &limiter{
+		bandwidth:      math.Float64bits(bandwidth),
+		now:            time.Now,
+		ctx:            context.Background(),
+		sleeper:        defaultSleeper{},
+		stallThreshold: defaultStallThreshold,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// closedChan is returned by done while no operation is in progress.
+var closedChan = func() chan struct{} {
+	c := make(chan struct{})
+	close(c)
+	return c
+}()
+
+// enter marks the start of an operation, which includes waiting for the
+// semaphore, if any. Each successful enter must be followed by leave.
+func (l *limiter) enter() error {
+	atomic.CompareAndSwapInt64(&l.firstUse, 0, time.Now().UnixNano())
+
+	l.activeMu.Lock()
+	if l.active == 0 {
+		l.idle = make(chan struct{})
+	}
+	l.active++
+	l.activeMu.Unlock()
+
+	if err := l.acquire(); err != nil {
+		l.deactivate()
+		return err
+	}
+	return nil
+}
+
+// leave marks the end of an operation started with enter.
+func (l *limiter) leave() {
+	l.release()
+	l.deactivate()
+}
+
+func (l *limiter) deactivate() {
+	l.activeMu.Lock()
+	l.active--
+	if l.active == 0 {
+		close(l.idle)
+	}
+	l.activeMu.Unlock()
+}
+
+// done returns a channel that is closed once no operation is in progress.
+func (l *limiter) done() <-chan struct{} {
+	l.activeMu.Lock()
+	defer l.activeMu.Unlock()
+
+	if l.active == 0 {
+		return closedChan
+	}
+	return l.idle
+}
+
+// begin prepares the limiter for the next operation. It fails if the
+// context of the limiter is done.
+func (l *limiter) begin() error {
+	if err := l.ctx.Err(); err != nil {
+		return err
+	}
+
+	if l.global != nil {
+		atomic.StoreInt64(&l.lastUse, time.Now().UnixNano())
+		l.global.register(l)
+	}
+	// The setup latency and the initial debt delay the first operation.
+	if wait := l.latency/2 + l.initialDebt; !l.isInitialized && wait > 0 {
+		if err := l.sleeper.Sleep(l.ctx, wait); err != nil {
+			return err
+		}
+	}
+	l.init()
+
+	if l.packetLatency > 0 {
+		if err := l.sleeper.Sleep(l.ctx, l.packetLatency/2); err != nil {
+			return err
+		}
+	}
+
+	if l.minInterval > 0 {
+		if !l.lastBegin.IsZero() {
+			if wait := l.minInterval - l.now().Sub(l.lastBegin); wait > 0 {
+				if err := l.sleeper.Sleep(l.ctx, wait); err != nil {
+					return err
+				}
+			}
+		}
+		l.lastBegin = l.now()
+	}
+
+	return nil
 }
 
 func (l *limiter) init() {
 	if !l.isInitialized {
 		l.reset()
+		l.bucket = -l.initialCredit
 		l.isInitialized = true
 	}
 }
 
 func (l *limiter) reset() {
 	l.bucket = 0
-	l.start = time.Now()
+	l.start = l.now()
+}
+
+// restart discards all accounting, i.e. the state of all strategies and the
+// stats, as if the limiter was new. The configuration is kept.
+func (l *limiter) restart() {
+	l.isInitialized = false
+	l.drained = time.Time{}
+	l.events, l.windowSum = nil, 0
+	l.burstStart = time.Time{}
+	l.backoffFactor = 0
+	l.isoStart, l.isoUsed = time.Time{}, 0
+	l.lastBegin = time.Time{}
+
+	atomic.StoreInt64(&l.sleptRequested, 0)
+	atomic.StoreInt64(&l.sleptActual, 0)
+	atomic.StoreInt64(&l.transferred, 0)
+	atomic.StoreInt64(&l.stalls, 0)
+	atomic.StoreInt64(&l.flushes, 0)
+	l.peak.reset()
+	atomic.StoreInt64(&l.firstUse, 0)
+}
+
+// getBandwidth returns the effective bandwidth, i.e. the configured bandwidth
+// capped at maxBandwidth, if any. It is wait-free and safe to call
+// concurrently with setBandwidth.
+func (l *limiter) getBandwidth() float64 {
+	bandwidth := math.Float64frombits(atomic.LoadUint64(&l.bandwidth))
+	if ceiling := float64(l.maxBandwidth); ceiling > 0 && (bandwidth <= 0 || bandwidth > ceiling) {
+		return ceiling
+	}
+	return bandwidth
+}
+
+// bandwidthInt returns the bandwidth rounded up to whole bytes per second, so
+// that a positive bandwidth never turns into zero, i.e. unlimited.
+func (l *limiter) bandwidthInt() int {
+	bandwidth := l.getBandwidth()
+	if bandwidth <= 0 {
+		return 0
+	}
+	return int(math.Ceil(bandwidth))
+}
+
+func (l *limiter) setBandwidth(bandwidth float64) {
+	atomic.StoreUint64(&l.bandwidth, math.Float64bits(bandwidth))
+}
+
+// resetFor starts a new bucket at the given time and notifies the reset
+// callback, if any.
+func (l *limiter) resetFor(reason string, start time.Time) {
+	l.bucket = 0
+	l.start = start
+	if reason == ResetStallDetected {
+		atomic.AddInt64(&l.stalls, 1)
+	}
+	if l.onReset != nil {
+		l.onReset(reason)
+	}
 }
 
-func (l *limiter) limit(n, bufSize int) {
+// limit accounts for n transferred bytes and sleeps if the bandwidth has been
+// exceeded. It returns the penalty it slept for.
+func (l *limiter) limit(n, bufSize int) (time.Duration, error) {
+	if l.rateLimiter != nil {
+		return l.waitRate(n)
+	}
+	if l.pacer != nil {
+		return l.pace(n, bufSize)
+	}
+	own := l.computePenalty(n, bufSize)
+	penalty := own
+	if l.pool != nil {
+		if poolPenalty := l.pool.computePenalty(n, bufSize); poolPenalty > penalty {
+			penalty = poolPenalty
+		}
+	}
+	// The operation itself took some of the time the penalty accounts for.
+	if penalty > 0 && l.syscallCost > 0 {
+		penalty -= l.syscallCost
+	}
+
+	slept, err := l.sleep(l.jitterPenalty(l.backoff(penalty)))
+
+	// The process CPU time does not advance while sleeping, so the new
+	// bucket starts after the sleep rather than after the penalty.
+	if l.cpuTime && own > 0 && !l.leaky && l.window == 0 && l.isoPeriod == 0 {
+		l.start = l.now()
+	}
+
+	return slept, err
+}
+
+// jitterPenalty multiplies the penalty by a random factor in
+// [1-jitter, 1+jitter]. The bucket strategy accounts for the deviation with
+// the next penalty, so the long-term average rate is preserved.
+func (l *limiter) jitterPenalty(penalty time.Duration) time.Duration {
+	if l.jitter <= 0 || penalty <= 0 {
+		return penalty
+	}
+	return time.Duration(float64(penalty) * (1 + l.jitter*(2*rand.Float64()-1)))
+}
+
+// backoff stretches consecutive penalties if exponential backoff is enabled.
+// The first penalty is taken as is, every following one is doubled up to
+// maxBackoffFactor times the computed penalty. An operation without penalty
+// resets the backoff.
+func (l *limiter) backoff(penalty time.Duration) time.Duration {
+	if !l.expBackoff {
+		return penalty
+	}
+	if penalty <= 0 {
+		l.backoffFactor = 0
+		return penalty
+	}
+
+	switch {
+	case l.backoffFactor == 0:
+		l.backoffFactor = 1
+	case l.backoffFactor < maxBackoffFactor:
+		l.backoffFactor *= 2
+	}
+	return penalty * l.backoffFactor
+}
+
+// computePenalty accounts for n transferred bytes and returns the penalty to
+// sleep for, if the bandwidth has been exceeded. It does not sleep itself, but
+// updates the state as if the penalty was slept.
+func (l *limiter) computePenalty(n, bufSize int) time.Duration {
+	if l.isoPeriod > 0 {
+		return l.isochronous(n)
+	}
+
+	l.adjustToDeadline()
+	bandwidth := l.getBandwidth()
+
 	// do not limit if desired bandwidth is zero or negative
-	if l.bandwidth <= 0 {
-		return
+	if bandwidth <= 0 {
+		return 0
+	}
+
+	switch {
+	case l.leaky:
+		return l.leak(n, bandwidth)
+	case l.window > 0:
+		return l.slide(n, bandwidth)
 	}
 
+	now := l.now()
+	l.capCredit(now, bandwidth)
 	l.bucket += int64(n)
-	bucketAge := time.Since(l.start)
-	penalty := time.Duration(l.bucket)*time.Second/time.Duration(l.bandwidth) - bucketAge
+	bucketAge := now.Sub(l.start)
+	penalty := time.Duration(float64(l.bucket)*float64(time.Second)/bandwidth) - bucketAge
 
-	if penalty > 0 {
-		time.Sleep(penalty)
-		l.reset()
-		return
+	// With slack, throttle only if the measured rate exceeds the bandwidth
+	// by more than the slack, i.e. bucket/bandwidth > bucketAge*(1+slack).
+	if penalty > time.Duration(float64(bucketAge)*l.slack) {
+		if l.burst(now, penalty, bucketAge) {
+			return 0
+		}
+
+		// Defer penalties below the minimum sleep. The deficit stays in
+		// the bucket and adds up to the next penalty.
+		if penalty < l.minSleep {
+			return 0
+		}
+
+		// The new bucket starts once the penalty has been paid.
+		l.burstStart = time.Time{}
+		l.resetFor(ResetPenaltyPaid, now.Add(penalty))
+		return penalty
 	}
+	l.burstStart = time.Time{}
 
 	// Prevent peak after stall. Compensate in case of large buffer
 	// and small bandwidth. TODO: The test cases could get more
 	// love.
-	compensation := time.Duration(bufSize/l.bandwidth) * time.Second
-	stallThreshold := time.Second + compensation
-	if bucketAge > stallThreshold {
-		l.reset()
+	if l.bufSizeHint > 0 {
+		bufSize = l.bufSizeHint
+	}
+	compensation := time.Duration(math.Floor(float64(bufSize)/bandwidth)) * time.Second
+	stallThreshold := l.stallThreshold + compensation
+	if l.stalled(now, bucketAge, stallThreshold) {
+		l.resetFor(ResetStallDetected, now)
+	}
+
+	return 0
+}
+
+// capCredit limits the credit accumulated during idle periods to
+// bucketCeiling bytes. It moves the start of the bucket forward, such that
+// bucketAge*bandwidth - bucket does not exceed the ceiling.
+func (l *limiter) capCredit(now time.Time, bandwidth float64) {
+	if l.bucketCeiling <= 0 {
+		return
+	}
+
+	earliest := now.Add(-time.Duration(float64(l.bucket+l.bucketCeiling) * float64(time.Second) / bandwidth))
+	if l.start.Before(earliest) {
+		l.start = earliest
+	}
+}
+
+// burst reports whether the soft limit lets an excessive rate through. It
+// does so as long as the rate stays below bandwidth*burstFactor, i.e.
+// bucket/bandwidth <= bucketAge*burstFactor, and the burst has not lasted
+// longer than burstDuration. The deficit stays in the bucket, so that the
+// eventual penalty restores the long-term average.
+func (l *limiter) burst(now time.Time, penalty, bucketAge time.Duration) bool {
+	if l.burstFactor <= 1 {
+		return false
+	}
+	if penalty+bucketAge > time.Duration(float64(bucketAge)*l.burstFactor) {
+		return false
+	}
+
+	if l.burstStart.IsZero() {
+		l.burstStart = now
+	}
+	return now.Sub(l.burstStart) <= l.burstDuration
+}
+
+// sleep sleeps for the given penalty and returns it, unless the penalty
+// exceeds the backpressure threshold or the context of the limiter is done
+// before the penalty has been slept.
+func (l *limiter) sleep(penalty time.Duration) (time.Duration, error) {
+	if penalty <= 0 {
+		return 0, nil
+	}
+	if l.backpressure > 0 && penalty > l.backpressure {
+		return 0, ErrBackpressureLimitExceeded
+	}
+	if err := l.flush(); err != nil {
+		return 0, err
+	}
+
+	start := l.now()
+	if err := l.sleeper.Sleep(l.ctx, penalty); err != nil {
+		return 0, err
+	}
+	atomic.AddInt64(&l.sleptRequested, int64(penalty))
+	atomic.AddInt64(&l.sleptActual, int64(l.now().Sub(start)))
+
+	return penalty, nil
+}
+
+// overheadRatio returns the total time actually slept divided by the total
+// time requested to sleep. It returns 1 if the limiter has not slept yet.
+func (l *limiter) overheadRatio() float64 {
+	requested := atomic.LoadInt64(&l.sleptRequested)
+	if requested == 0 {
+		return 1
+	}
+	return float64(atomic.LoadInt64(&l.sleptActual)) / float64(requested)
+}
+
+// leak implements the leaky bucket strategy. The bucket drains at exactly the
+// configured bandwidth and never builds up credits, so each operation waits
+// until the bytes of the previous operations have drained. It returns the
+// penalty to sleep for.
+func (l *limiter) leak(n int, bandwidth float64) time.Duration {
+	now := l.now()
+	if l.drained.Before(now) {
+		l.drained = now
+	}
+
+	penalty := l.drained.Sub(now)
+	l.drained = l.drained.Add(time.Duration(float64(n) * float64(time.Second) / bandwidth))
+
+	return penalty
+}
+
+// isochronous implements the isochronous mode. Each period provides isoBytes
+// bytes; once they are used up, the penalty lasts until the end of the
+// period. Bytes left unused at the end of a period are forfeited, so the
+// transfer keeps a fixed cadence instead of an average rate.
+func (l *limiter) isochronous(n int) time.Duration {
+	now := l.now()
+	if l.isoStart.IsZero() {
+		l.isoStart = now
+	}
+	if elapsed := now.Sub(l.isoStart); elapsed >= l.isoPeriod {
+		l.isoStart = l.isoStart.Add(elapsed / l.isoPeriod * l.isoPeriod)
+		l.isoUsed = 0
+	}
+
+	l.isoUsed += int64(n)
+	if l.isoUsed < l.isoBytes {
+		return 0
+	}
+
+	// Skip as many periods as the bytes have used up.
+	periods := l.isoUsed / l.isoBytes
+	l.isoStart = l.isoStart.Add(time.Duration(periods) * l.isoPeriod)
+	l.isoUsed -= periods * l.isoBytes
+
+	return l.isoStart.Sub(now)
+}
+
+// windowEvent records a number of bytes transferred at a point in time.
+type windowEvent struct {
+	ts time.Time
+	n  int64
+}
+
+// slide implements the sliding window strategy. It returns the penalty to
+// sleep for until the bytes transferred within the last window plus n fit
+// into bandwidth*window.
+func (l *limiter) slide(n int, bandwidth float64) time.Duration {
+	capacity := int64(bandwidth * l.window.Seconds())
+	now := l.now()
+
+	// Expire events that have left the window.
+	for len(l.events) > 0 && !l.events[0].ts.Add(l.window).After(now) {
+		l.windowSum -= l.events[0].n
+		l.events = l.events[1:]
+	}
+
+	// Wait for as many events to leave the window as needed to make room.
+	var penalty time.Duration
+	for len(l.events) > 0 && l.windowSum+int64(n) > capacity {
+		penalty = l.events[0].ts.Add(l.window).Sub(now)
+		l.windowSum -= l.events[0].n
+		l.events = l.events[1:]
+	}
+
+	if penalty > 0 {
+		now = now.Add(penalty)
+	}
+
+	l.events = append(l.events, windowEvent{ts: now, n: int64(n)})
+	l.windowSum += int64(n)
+
+	return penalty
+}
+
+// record accounts an operation in the stats and reports it to the operation
+// logger, if any.
+func (l *limiter) record(op string, n int, penalty time.Duration) {
+	atomic.AddInt64(&l.transferred, int64(n))
+	l.peak.add(l.now(), n)
+	if l.opLogger != nil {
+		l.opLogger(op, n, penalty, time.Now())
 	}
 }
 
 // Reader wraps another reader and maintains a given bandwidth.
 type Reader struct {
-	lim limiter
+	lim *limiter
 	src io.Reader
 }
 
 // NewReader returns a new reader that wraps reader r and maintains the
 // given bandwidth. If bandwidth is zero or negative, the Reader will not
 // limit.
-func NewReader(r io.Reader, bandwidth int) *Reader {
+func NewReader(r io.Reader, bandwidth int, opts ...Option) *Reader {
+	return NewReaderF(r, float64(bandwidth), opts...)
+}
+
+// NewReaderSize returns a new reader like NewReader, except that the stall
+// detection compensates for bufSizeHint instead of the size of the buffer
+// passed to each Read. This is more stable if callers use varying buffer
+// sizes. If bufSizeHint is zero or negative, NewReaderSize behaves like
+// NewReader.
+func NewReaderSize(r io.Reader, bandwidth, bufSizeHint int, opts ...Option) *Reader {
+	reader := NewReader(r, bandwidth, opts...)
+	reader.lim.bufSizeHint = bufSizeHint
+	return reader
+}
+
+// NewReaderF returns a new reader like NewReader, except that bandwidth is a
+// float64. This allows for rates below one byte per second, e.g. 0.1 for a
+// teletype demo.
+func NewReaderF(r io.Reader, bandwidth float64, opts ...Option) *Reader {
 	reader := &Reader{
 		src: r,
-		lim: limiter{bandwidth: bandwidth},
+		lim: newLimiter(bandwidth, opts),
 	}
 	return reader
 }
 
 // Read implements the io.Reader interface and maintains a given bandwidth.
 func (r *Reader) Read(p []byte) (n int, err error) {
-	r.lim.init()
+	if err := r.lim.enter(); err != nil {
+		return 0, err
+	}
+	defer r.lim.leave()
+
+	if err := r.lim.begin(); err != nil {
+		return 0, err
+	}
 
 	n, err = r.src.Read(p)
 	if err != nil {
 		// return all err, including io.EOF
-		return n, err
+		r.lim.record("read", n, 0)
+		return n, r.lim.wrapError(err)
 	}
 
-	r.lim.limit(n, len(p))
+	penalty, err := r.lim.limit(n, len(p))
+	r.lim.record("read", n, penalty)
 
 	return n, err
 }
 
+// SetBandwidth changes the bandwidth of the Reader. If bandwidth is zero or
+// negative, the Reader will not limit. It is safe to call SetBandwidth
+// concurrently with Read.
+func (r *Reader) SetBandwidth(bandwidth int) {
+	r.lim.setBandwidth(float64(bandwidth))
+}
+
+// Done returns a channel that is closed once no Read is in progress,
+// including its penalty sleep. It lets callers wait for the Reader to settle
+// before they close the underlying stream. If no Read is in progress, the
+// channel is already closed.
+func (r *Reader) Done() <-chan struct{} {
+	return r.lim.done()
+}
+
+// Reset restarts the bandwidth accounting from scratch and clears the stats,
+// e.g. before the Reader is reused for another transfer. The configuration,
+// including the bandwidth, is kept. Reset must not be called concurrently
+// with Read.
+func (r *Reader) Reset() {
+	r.lim.restart()
+}
+
+// Rewrap replaces the wrapped reader with src, but keeps the state of the
+// limiter. Sequential transfers, e.g. of several files, thus share the
+// bandwidth without gaps or bursts at the transitions. Rewrap must not be
+// called concurrently with Read.
+func (r *Reader) Rewrap(src io.Reader) {
+	r.src = src
+}
+
+// Bandwidth returns the bandwidth the Reader currently maintains, including
+// the ceiling of WithCgroupBandwidth. Fractional bandwidths are rounded up.
+// It is safe to call Bandwidth concurrently with Read and SetBandwidth.
+func (r *Reader) Bandwidth() int {
+	return r.lim.bandwidthInt()
+}
+
+// WriteTo implements the io.WriterTo interface. It reads from the wrapped
+// reader, maintains the bandwidth just like Read and writes to w until EOF or
+// an error occurs. It lets io.Copy honour the limit regardless of the
+// interfaces implemented by the wrapped reader or w.
+//
+// WriteTo reads in chunks of the buffer size hint of NewReaderSize, if any,
+// or else of a tenth of a second worth of data, see SmartCopy. Note that
+// io.Copy and io.CopyBuffer prefer WriteTo, so io.CopyBuffer ignores the
+// buffer passed to it. To control the size of the reads, hide WriteTo, e.g.
+// with io.CopyBuffer(dst, struct{ io.Reader }{r}, buf).
+func (r *Reader) WriteTo(w io.Writer) (n int64, err error) {
+	bufSize := r.lim.bufSizeHint
+	if bufSize <= 0 {
+		bufSize = smartBufSize(r.lim.bandwidthInt())
+	}
+	buf := make([]byte, bufSize)
+	for {
+		nr, rerr := r.Read(buf)
+		if nr > 0 {
+			nw, werr := w.Write(buf[:nr])
+			n += int64(nw)
+			if werr != nil {
+				return n, werr
+			}
+			if nw != nr {
+				return n, io.ErrShortWrite
+			}
+		}
+		if rerr == io.EOF {
+			return n, nil
+		}
+		if rerr != nil {
+			return n, rerr
+		}
+	}
+}
+
+// ReadByte implements the io.ByteReader interface. It reads a single byte
+// from the wrapped reader and maintains the bandwidth just like Read, so
+// decoders that prefer ReadByte cannot bypass the limit. Once the byte has
+// been read, ReadByte returns it without error; an io.EOF of the wrapped
+// reader is returned by the next call.
+func (r *Reader) ReadByte() (byte, error) {
+	var b [1]byte
+	for {
+		n, err := r.Read(b[:])
+		if n == 1 {
+			return b[0], nil
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+}
+
+// ReadRune implements the io.RuneReader interface. If the wrapped reader
+// implements io.RuneReader, ReadRune calls it and accounts for the size of
+// the rune in bytes. Otherwise, it reads the rune byte by byte with ReadByte.
+// Either way, it maintains the bandwidth just like Read.
+func (r *Reader) ReadRune() (ch rune, size int, err error) {
+	rr, ok := r.src.(io.RuneReader)
+	if !ok {
+		return r.readRuneBytes()
+	}
+
+	if err := r.lim.enter(); err != nil {
+		return 0, 0, err
+	}
+	defer r.lim.leave()
+
+	if err := r.lim.begin(); err != nil {
+		return 0, 0, err
+	}
+
+	ch, size, err = rr.ReadRune()
+	if err != nil {
+		r.lim.record("read", size, 0)
+		return ch, size, r.lim.wrapError(err)
+	}
+
+	penalty, err := r.lim.limit(size, size)
+	r.lim.record("read", size, penalty)
+
+	return ch, size, err
+}
+
+// readRuneBytes reads a single UTF-8 encoded rune with ReadByte. It stops as
+// soon as the bytes read form a full rune, or an invalid sequence, which is
+// returned as utf8.RuneError with the number of bytes consumed.
+func (r *Reader) readRuneBytes() (rune, int, error) {
+	var buf [utf8.UTFMax]byte
+	n := 0
+	for n < len(buf) && !utf8.FullRune(buf[:n]) {
+		b, err := r.ReadByte()
+		if err != nil {
+			if n == 0 {
+				return 0, 0, err
+			}
+			break
+		}
+		buf[n] = b
+		n++
+	}
+	// Without a way to unread, an invalid sequence is consumed as a whole.
+	ch, _ := utf8.DecodeRune(buf[:n])
+	return ch, n, nil
+}
+
+// OverheadRatio returns the total time the Reader actually slept divided by
+// the total time it requested to sleep. A ratio above one means the OS
+// oversleeps; it is one as long as the Reader has not slept.
+func (r *Reader) OverheadRatio() float64 {
+	return r.lim.overheadRatio()
+}
+
 // Writer wraps another writer and maintains a given bandwidth.
 type Writer struct {
-	lim limiter
+	lim *limiter
 	dst io.Writer
+	mtu int
 }
 
 // NewWriter returns a new writer that wraps writer d and maintains a given
 // bandwidth. If bandwidth is zero or negative, the Writer will not limit.
-func NewWriter(d io.Writer, bandwidth int) *Writer {
+func NewWriter(d io.Writer, bandwidth int, opts ...Option) *Writer {
+	return NewWriterF(d, float64(bandwidth), opts...)
+}
+
+// NewWriterF returns a new writer like NewWriter, except that bandwidth is a
+// float64. This allows for rates below one byte per second, e.g. 0.1 for a
+// teletype demo.
+func NewWriterF(d io.Writer, bandwidth float64, opts ...Option) *Writer {
 	writer := &Writer{
 		dst: d,
-		lim: limiter{bandwidth: bandwidth},
+		lim: newLimiter(bandwidth, opts),
 	}
 	return writer
 }
 
+// NewMTUWriter returns a new writer like NewWriter, except that writes
+// larger than mtu are split into mtu sized chunks. Each chunk is written and
+// limited on its own, so a single large write cannot jump ahead of the
+// limiter. If mtu is zero or negative, writes are not split.
+func NewMTUWriter(w io.Writer, mtu, bandwidth int, opts ...Option) *Writer {
+	writer := NewWriter(w, bandwidth, opts...)
+	writer.mtu = mtu
+	return writer
+}
+
 // Write implements the io.Writer interface and maintains the given bandwidth.
 func (w *Writer) Write(p []byte) (n int, err error) {
-	w.lim.init()
+	if err := w.lim.enter(); err != nil {
+		return 0, err
+	}
+	defer w.lim.leave()
+
+	if err := w.lim.begin(); err != nil {
+		return 0, err
+	}
 
+	if w.mtu <= 0 || len(p) <= w.mtu {
+		return w.write(p)
+	}
+
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > w.mtu {
+			chunk = chunk[:w.mtu]
+		}
+		m, err := w.write(chunk)
+		n += m
+		if err != nil {
+			return n, err
+		}
+		p = p[m:]
+	}
+
+	return n, nil
+}
+
+// SetBandwidth changes the bandwidth of the Writer. If bandwidth is zero or
+// negative, the Writer will not limit. It is safe to call SetBandwidth
+// concurrently with Write.
+func (w *Writer) SetBandwidth(bandwidth int) {
+	w.lim.setBandwidth(float64(bandwidth))
+}
+
+// Done returns a channel that is closed once no Write is in progress,
+// including its penalty sleep. It lets callers wait for the Writer to settle
+// before they close the underlying stream. If no Write is in progress, the
+// channel is already closed.
+func (w *Writer) Done() <-chan struct{} {
+	return w.lim.done()
+}
+
+// Reset restarts the bandwidth accounting from scratch and clears the stats,
+// e.g. before the Writer is reused for another transfer. The configuration,
+// including the bandwidth, is kept. Reset must not be called concurrently
+// with Write.
+func (w *Writer) Reset() {
+	w.lim.restart()
+}
+
+// Rewrap replaces the wrapped writer with dst, but keeps the state of the
+// limiter. Sequential transfers, e.g. of several files, thus share the
+// bandwidth without gaps or bursts at the transitions. Rewrap must not be
+// called concurrently with Write.
+func (w *Writer) Rewrap(dst io.Writer) {
+	w.dst = dst
+}
+
+// Bandwidth returns the bandwidth the Writer currently maintains, including
+// the ceiling of WithCgroupBandwidth. Fractional bandwidths are rounded up.
+// It is safe to call Bandwidth concurrently with Write and SetBandwidth.
+func (w *Writer) Bandwidth() int {
+	return w.lim.bandwidthInt()
+}
+
+// WriteString implements the io.StringWriter interface. It writes the bytes
+// of s like Write, so the limiter accounts for len(s) bytes.
+func (w *Writer) WriteString(s string) (n int, err error) {
+	return w.Write([]byte(s))
+}
+
+// WriteByte implements the io.ByteWriter interface. It writes a single byte
+// to the wrapped writer and maintains the bandwidth just like Write.
+func (w *Writer) WriteByte(c byte) error {
+	_, err := w.Write([]byte{c})
+	return err
+}
+
+// OverheadRatio returns the total time the Writer actually slept divided by
+// the total time it requested to sleep. A ratio above one means the OS
+// oversleeps; it is one as long as the Writer has not slept.
+func (w *Writer) OverheadRatio() float64 {
+	return w.lim.overheadRatio()
+}
+
+func (w *Writer) write(p []byte) (n int, err error) {
 	n, err = w.dst.Write(p)
 	if err != nil {
-		return n, err
+		w.lim.record("write", n, 0)
+		return n, w.lim.wrapError(err)
 	}
 
-	w.lim.limit(n, len(p))
+	penalty, err := w.lim.limit(n, len(p))
+	w.lim.record("write", n, penalty)
 
 	return n, err
 }
@@ -148,11 +946,100 @@ func Copy(dst io.Writer, src io.Reader, bandwidth int) (written int64, err error
 
 // CopyBuffer copies the same way io.CopyBuffer does, except maintaining the
 // given bandwidth. If buf is nil, CopyBuffer will create a buffer with size of
-// 16 KiBytes. If bandwidth is zero or negative, the copy will not be limited.
+// 16 KiBytes. If src implements io.WriterTo, CopyBuffer does not use buf, but
+// lets src write to dst in chunks of len(buf) bytes. If bandwidth is zero or
+// negative, the copy will not be limited. Like io.CopyBuffer, it returns the
+// number of bytes written to dst up to an error, if any.
 func CopyBuffer(dst io.Writer, src io.Reader, bandwidth int, buf []byte) (written int64, err error) {
+	return CopyBufferContext(context.Background(), dst, src, bandwidth, buf)
+}
+
+// CopyContext copies the same way Copy does, except that it stops once ctx
+// is done and returns ctx.Err(). The copy is interrupted between chunks or
+// during a penalty sleep, whichever comes first.
+func CopyContext(ctx context.Context, dst io.Writer, src io.Reader, bandwidth int) (written int64, err error) {
+	return CopyBufferContext(ctx, dst, src, bandwidth, nil)
+}
+
+// CopyBufferContext copies the same way CopyBuffer does, except that it stops
+// once ctx is done and returns ctx.Err(). The copy is interrupted between
+// chunks or during a penalty sleep, whichever comes first.
+func CopyBufferContext(ctx context.Context, dst io.Writer, src io.Reader, bandwidth int, buf []byte) (written int64, err error) {
+	// A src with its own buffer, e.g. a bytes.Buffer, writes directly to a
+	// limited dst, which splits its writes into chunks of the buffer size.
+	if wt, ok := src.(io.WriterTo); ok {
+		chunk := len(buf)
+		if chunk == 0 {
+			chunk = defaultBufSize
+		}
+		return wt.WriteTo(NewChunkedWriter(dst, chunk, bandwidth, WithContext(ctx)))
+	}
+
 	if len(buf) == 0 {
-		buf = make([]byte, 16<<10)
+		buf = make([]byte, defaultBufSize)
 	}
-	bwReader := NewReader(src, bandwidth)
-	return io.CopyBuffer(dst, bwReader, buf)
+	// Hide io.WriterTo of the Reader and io.ReaderFrom of dst from
+	// io.CopyBuffer, so that all data flows through buf and the limiter.
+	bwReader := struct{ io.Reader }{NewReader(src, bandwidth, WithContext(ctx))}
+	return io.CopyBuffer(struct{ io.Writer }{dst}, bwReader, buf)
+}
+
+// CopyBufferWriter copies the same way CopyBuffer does, except that it
+// throttles the writes to dst instead of the reads from src. This suits
+// sources that should be drained as fast as possible, e.g. to free a network
+// buffer, while the destination is the bottleneck to model.
+func CopyBufferWriter(dst io.Writer, src io.Reader, bandwidth int, buf []byte) (written int64, err error) {
+	if len(buf) == 0 {
+		buf = make([]byte, defaultBufSize)
+	}
+	// Hide io.WriterTo of src and io.ReaderFrom of the Writer from
+	// io.CopyBuffer, so that all data flows through buf and the limiter.
+	bwWriter := struct{ io.Writer }{NewWriter(dst, bandwidth)}
+	return io.CopyBuffer(bwWriter, struct{ io.Reader }{src}, buf)
+}
+
+// CopyBufferSize copies the same way CopyBuffer does, except that it
+// allocates a buffer of bufSize bytes. If bufSize is zero or negative,
+// CopyBufferSize uses a buffer size of 16 KiBytes.
+func CopyBufferSize(dst io.Writer, src io.Reader, bandwidth int, bufSize int) (written int64, err error) {
+	if bufSize <= 0 {
+		bufSize = defaultBufSize
+	}
+	return CopyBuffer(dst, src, bandwidth, make([]byte, bufSize))
+}
+
+// Buffer size bounds of SmartCopy.
+const (
+	minSmartBufSize = 512
+	maxSmartBufSize = 1 << 20
+)
+
+// SmartCopy copies the same way CopyBuffer does, except that it picks the
+// buffer size from the bandwidth: a tenth of a second worth of data, at least
+// 512 bytes and at most 1 MiByte. This gives a reasonable sleep granularity
+// without tuning. If bandwidth is zero or negative, SmartCopy uses a buffer
+// size of 16 KiBytes and does not limit.
+func SmartCopy(dst io.Writer, src io.Reader, bandwidth int) (written int64, err error) {
+	return CopyBufferSize(dst, src, bandwidth, smartBufSize(bandwidth))
+}
+
+// smartBufSize returns the buffer size of SmartCopy.
+func smartBufSize(bandwidth int) int {
+	switch {
+	case bandwidth <= 0:
+		return defaultBufSize
+	case bandwidth/10 < minSmartBufSize:
+		return minSmartBufSize
+	case bandwidth/10 > maxSmartBufSize:
+		return maxSmartBufSize
+	}
+	return bandwidth / 10
+}
+
+// ReadFull reads exactly len(buf) bytes from r into buf the same way
+// io.ReadFull does, except maintaining the given bandwidth across all reads
+// needed to fill buf. If bandwidth is zero or negative, the reads will not be
+// limited.
+func ReadFull(r io.Reader, buf []byte, bandwidth int) (n int, err error) {
+	return io.ReadFull(NewReader(r, bandwidth), buf)
 }
@@ -18,12 +18,17 @@ package bwio
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"io"
 	"io/ioutil"
+	"math"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
+	"unicode/utf8"
 )
 
 type BWTestReader struct {
@@ -90,6 +95,47 @@ func TestWrite(t *testing.T) {
 	}
 }
 
+type recordingWriter struct {
+	sizes []int
+}
+
+func (w *recordingWriter) Write(p []byte) (int, error) {
+	w.sizes = append(w.sizes, len(p))
+	return len(p), nil
+}
+
+func TestMTUWriter(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name string
+		mtu  int
+		size int
+		want []int
+	}{
+		{"split", 1500, 4000, []int{1500, 1500, 1000}},
+		{"exact", 1500, 3000, []int{1500, 1500}},
+		{"small", 1500, 100, []int{100}},
+		{"disabled", 0, 4000, []int{4000}},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			rec := new(recordingWriter)
+			mw := NewMTUWriter(rec, testc.mtu, 0)
+			n, err := mw.Write(make([]byte, testc.size))
+			if err != nil {
+				t.Error(err)
+			}
+			if n != testc.size {
+				t.Errorf("Want %d bytes, got %d.", testc.size, n)
+			}
+			if !reflect.DeepEqual(rec.sizes, testc.want) {
+				t.Errorf("Want chunks %v, got %v.", testc.want, rec.sizes)
+			}
+		})
+	}
+}
+
 func TestCopy(t *testing.T) {
 	t.Parallel()
 
@@ -111,6 +157,280 @@ func TestCopy(t *testing.T) {
 
 }
 
+func TestCopyBufferSize(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name     string
+		size     int
+		bufSize  int
+		want     []int
+		writerTo bool
+	}{
+		{"custom", 10000, 3000, []int{3000, 3000, 3000, 1000}, false},
+		{"default", 20000, 0, []int{16 << 10, 20000 - 16<<10}, false},
+		{"custom WriterTo", 10000, 3000, []int{3000, 3000, 3000, 1000}, true},
+		{"default WriterTo", 20000, 0, []int{16 << 10, 20000 - 16<<10}, true},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			// A bytes.Reader implements io.WriterTo, which takes the
+			// write side path of CopyBuffer unless it is hidden.
+			var src io.Reader = bytes.NewReader(make([]byte, testc.size))
+			if !testc.writerTo {
+				src = struct{ io.Reader }{src}
+			}
+			rec := new(recordingWriter)
+			n, err := CopyBufferSize(rec, src, 0, testc.bufSize)
+			if err != nil {
+				t.Error(err)
+			}
+			if n != int64(testc.size) {
+				t.Errorf("Want %d bytes, got %d.", testc.size, n)
+			}
+			if !reflect.DeepEqual(rec.sizes, testc.want) {
+				t.Errorf("Want chunks %v, got %v.", testc.want, rec.sizes)
+			}
+		})
+	}
+}
+
+// readerFromWriter records writes like recordingWriter, but also implements
+// io.ReaderFrom, which CopyBuffer must not use.
+type readerFromWriter struct {
+	recordingWriter
+	readFrom bool
+}
+
+func (w *readerFromWriter) ReadFrom(r io.Reader) (int64, error) {
+	w.readFrom = true
+	return io.Copy(&w.recordingWriter, r)
+}
+
+func TestCopyBufferReaderFrom(t *testing.T) {
+	t.Parallel()
+
+	dst := new(readerFromWriter)
+	src := struct{ io.Reader }{bytes.NewReader(make([]byte, 5000))}
+	n, err := CopyBuffer(dst, src, 0, make([]byte, 2000))
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 5000 {
+		t.Errorf("Want 5000 bytes, got %d.", n)
+	}
+	if dst.readFrom {
+		t.Error("Want ReadFrom of dst to be bypassed.")
+	}
+	if want := []int{2000, 2000, 1000}; !reflect.DeepEqual(dst.sizes, want) {
+		t.Errorf("Want chunks %v, got %v.", want, dst.sizes)
+	}
+}
+
+// writerToSpy records whether its WriteTo has been used.
+type writerToSpy struct {
+	*bytes.Buffer
+	writeTo bool
+}
+
+func (s *writerToSpy) WriteTo(w io.Writer) (int64, error) {
+	s.writeTo = true
+	return s.Buffer.WriteTo(w)
+}
+
+func TestCopyBufferWriterTo(t *testing.T) {
+	t.Parallel()
+
+	src := &writerToSpy{Buffer: bytes.NewBuffer(make([]byte, 5000))}
+	dst := new(recordingWriter)
+
+	start := time.Now()
+	n, err := CopyBuffer(dst, src, 50000, make([]byte, 2000))
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 5000 {
+		t.Errorf("Want 5000 bytes, got %d.", n)
+	}
+	if !src.writeTo {
+		t.Error("Want WriteTo of src to be used.")
+	}
+	if want := []int{2000, 2000, 1000}; !reflect.DeepEqual(dst.sizes, want) {
+		t.Errorf("Want chunks %v, got %v.", want, dst.sizes)
+	}
+	if dur < 80*time.Millisecond || dur > 200*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
+
+// fullWriter accepts size bytes and fails with errPoison afterwards.
+type fullWriter struct {
+	size int
+}
+
+func (w *fullWriter) Write(p []byte) (int, error) {
+	if len(p) > w.size {
+		n := w.size
+		w.size = 0
+		return n, errPoison
+	}
+	w.size -= len(p)
+	return len(p), nil
+}
+
+func TestCopyBufferPartialWrite(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name string
+		src  io.Reader
+	}{
+		{"WriterTo", bytes.NewReader(make([]byte, 5000))},
+		{"Reader", struct{ io.Reader }{bytes.NewReader(make([]byte, 5000))}},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			n, err := CopyBuffer(&fullWriter{size: 2500}, testc.src, 100000, make([]byte, 1000))
+			if !errors.Is(err, errPoison) {
+				t.Errorf("Want %v, got %v.", errPoison, err)
+			}
+			if n != 2500 {
+				t.Errorf("Want 2500 bytes, got %d.", n)
+			}
+		})
+	}
+}
+
+// TestCopyBuffer_WriterSide does not run in parallel, because it compares
+// the achieved rates of two copies closely.
+func TestCopyBuffer_WriterSide(t *testing.T) {
+	const (
+		size      = 40 << 10
+		bandwidth = 200 << 10
+	)
+
+	copies := []struct {
+		name string
+		copy func(dst io.Writer, src io.Reader, bandwidth int, buf []byte) (int64, error)
+	}{
+		{"reader", CopyBuffer},
+		{"writer", CopyBufferWriter},
+	}
+
+	rates := make([]float64, len(copies))
+	for i, c := range copies {
+		// Hide io.WriterTo of the bytes.Reader, which would make
+		// CopyBuffer limit the write side as well.
+		src := struct{ io.Reader }{bytes.NewReader(make([]byte, size))}
+		start := time.Now()
+		n, err := c.copy(ioutil.Discard, src, bandwidth, make([]byte, 1<<10))
+		dur := time.Since(start)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != size {
+			t.Errorf("%s: want %d bytes, got %d.", c.name, size, n)
+		}
+		rates[i] = float64(n) / dur.Seconds()
+	}
+
+	if diff := math.Abs(rates[0]-rates[1]) / rates[0]; diff > 0.05 {
+		t.Errorf("Want rates to differ by less than 5%%, got %.0f B/s (reader) and %.0f B/s (writer).", rates[0], rates[1])
+	}
+}
+
+func TestSetBandwidthConcurrent(t *testing.T) {
+	t.Parallel()
+
+	br := NewReader(bytes.NewReader(make([]byte, 1<<20)), 1<<30)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for bw := 1 << 30; bw > 1<<20; bw /= 2 {
+			br.SetBandwidth(bw)
+		}
+	}()
+
+	n, err := io.Copy(ioutil.Discard, br)
+	<-done
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 1<<20 {
+		t.Errorf("Want %d bytes, got %d.", 1<<20, n)
+	}
+}
+
+func TestBandwidth(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(new(bytes.Buffer), 1000)
+	if got := r.Bandwidth(); got != 1000 {
+		t.Errorf("Want bandwidth 1000, got %d.", got)
+	}
+	r.SetBandwidth(2000)
+	if got := r.Bandwidth(); got != 2000 {
+		t.Errorf("Want bandwidth 2000, got %d.", got)
+	}
+
+	w := NewWriterF(ioutil.Discard, 0.5)
+	if got := w.Bandwidth(); got != 1 {
+		t.Errorf("Want bandwidth 1, got %d.", got)
+	}
+	w.SetBandwidth(-1)
+	if got := w.Bandwidth(); got != 0 {
+		t.Errorf("Want bandwidth 0, got %d.", got)
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	t.Parallel()
+
+	src := &chunkReader{r: bytes.NewReader(make([]byte, 300)), size: 50}
+	buf := make([]byte, 200)
+
+	start := time.Now()
+	n, err := ReadFull(src, buf, 1000)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 200 {
+		t.Errorf("Want 200 bytes, got %d.", n)
+	}
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+
+	n, err = ReadFull(src, buf, 1000)
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Want %v, got %v.", io.ErrUnexpectedEOF, err)
+	}
+	if n != 100 {
+		t.Errorf("Want 100 bytes, got %d.", n)
+	}
+}
+
+func TestFractionalBandwidth(t *testing.T) {
+	t.Parallel()
+
+	l := NewReaderF(nil, 0.5).lim
+	l.init()
+	if penalty := l.computePenalty(1, 1); penalty < 1900*time.Millisecond || penalty > 2*time.Second {
+		t.Errorf("Want penalty of 2s for one byte at 0.5 B/s, got %s.", penalty)
+	}
+
+	w := NewWriterF(ioutil.Discard, 2500.5)
+	if got := w.lim.getBandwidth(); got != 2500.5 {
+		t.Errorf("Want bandwidth 2500.5, got %f.", got)
+	}
+}
+
 func TestPanicRegression(t *testing.T) {
 	t.Parallel()
 
@@ -160,7 +480,7 @@ func TestError(t *testing.T) {
 		t.Run("read"+bws, func(t *testing.T) {
 			lr := NewReader(new(pReader), bw)
 			_, err := io.Copy(ioutil.Discard, lr)
-			if err != errPoison {
+			if !errors.Is(err, errPoison) {
 				t.Errorf("Want %v, got %v", errPoison, err)
 			}
 		})
@@ -168,28 +488,475 @@ func TestError(t *testing.T) {
 			lw := NewWriter(new(pWriter), bw)
 			r := oneByteReader()
 			_, err := io.Copy(lw, r)
-			if err != errPoison {
+			if !errors.Is(err, errPoison) {
 				t.Errorf("Want %v, got %v", errPoison, err)
 			}
 		})
 		t.Run("copyR"+bws, func(t *testing.T) {
 			_, err := Copy(ioutil.Discard, new(pReader), bw)
-			if err != errPoison {
+			if !errors.Is(err, errPoison) {
 				t.Errorf("Want %v, got %v", errPoison, err)
 			}
 		})
 		t.Run("copyW"+bws, func(t *testing.T) {
 			r := oneByteReader()
 			_, err := Copy(new(pWriter), r, bw)
-			if err != errPoison {
+			if !errors.Is(err, errPoison) {
 				t.Errorf("Want %v, got %v", errPoison, err)
 			}
 		})
 		t.Run("copyRW"+bws, func(t *testing.T) {
 			_, err := Copy(new(pWriter), new(pReader), bw)
-			if err != errPoison {
+			if !errors.Is(err, errPoison) {
 				t.Errorf("Want %v, got %v", errPoison, err)
 			}
 		})
 	}
 }
+
+func TestOverheadRatio(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 10000)
+	if ratio := r.OverheadRatio(); ratio != 1 {
+		t.Errorf("Want ratio 1 before sleeping, got %f.", ratio)
+	}
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Error(err)
+	}
+	if ratio := r.OverheadRatio(); ratio < 1 {
+		t.Errorf("Want ratio >= 1, got %f.", ratio)
+	}
+
+	// A closed channelSleeper does not sleep at all.
+	c := make(channelSleeper)
+	close(c)
+	w := NewWriter(ioutil.Discard, 10)
+	w.lim.sleeper = c
+	if _, err := w.Write(make([]byte, 1000)); err != nil {
+		t.Error(err)
+	}
+	if ratio := w.OverheadRatio(); ratio >= 0.5 {
+		t.Errorf("Want ratio near 0, got %f.", ratio)
+	}
+}
+
+func TestReadByte(t *testing.T) {
+	t.Parallel()
+
+	var _ io.ByteReader = (*Reader)(nil)
+
+	r := NewReader(bytes.NewReader([]byte("abc")), 20)
+
+	// Reading 3 bytes at 20 B/s takes 150ms.
+	start := time.Now()
+	var got []byte
+	for {
+		b, err := r.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, b)
+	}
+	dur := time.Since(start)
+	if string(got) != "abc" {
+		t.Errorf("Want %q, got %q.", "abc", got)
+	}
+	if dur < 100*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 150ms.", dur)
+	}
+}
+
+func TestReadRune(t *testing.T) {
+	t.Parallel()
+
+	var _ io.RuneReader = (*Reader)(nil)
+
+	// "aä€" consists of 1+2+3 bytes, invalid sequences are consumed as a
+	// whole by the fallback.
+	const text = "aä€\xe2("
+
+	testt := []struct {
+		name string
+		src  io.Reader
+		want []rune
+	}{
+		{"RuneReader", strings.NewReader(text), []rune{'a', 'ä', '€', utf8.RuneError, '('}},
+		{"fallback", struct{ io.Reader }{strings.NewReader(text)}, []rune{'a', 'ä', '€', utf8.RuneError}},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Reading 8 bytes at 40 B/s takes 200ms.
+			r := NewReader(testc.src, 40)
+
+			start := time.Now()
+			var got []rune
+			var total int
+			for {
+				ch, size, err := r.ReadRune()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, ch)
+				total += size
+			}
+			dur := time.Since(start)
+
+			if !reflect.DeepEqual(got, testc.want) {
+				t.Errorf("Want %q, got %q.", testc.want, got)
+			}
+			if total != len(text) {
+				t.Errorf("Want %d bytes, got %d.", len(text), total)
+			}
+			if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+				t.Errorf("Took %s, want 200ms.", dur)
+			}
+		})
+	}
+}
+
+func TestWriteByte(t *testing.T) {
+	t.Parallel()
+
+	var _ io.ByteWriter = (*Writer)(nil)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 20)
+
+	// Writing 3 bytes at 20 B/s takes 150ms.
+	start := time.Now()
+	for _, c := range []byte("abc") {
+		if err := w.WriteByte(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dur := time.Since(start)
+	if buf.String() != "abc" {
+		t.Errorf("Want %q, got %q.", "abc", buf.String())
+	}
+	if dur < 100*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 150ms.", dur)
+	}
+}
+
+func TestWriteTo(t *testing.T) {
+	t.Parallel()
+
+	var _ io.WriterTo = (*Reader)(nil)
+
+	// bytes.Reader implements io.WriterTo itself, which io.Copy must not
+	// use to bypass the limit.
+	data := []byte("hello world")
+	r := NewReader(bytes.NewReader(data), 50)
+	rec := new(recordingWriter)
+
+	// Reading 11 bytes at 50 B/s takes 220ms.
+	start := time.Now()
+	n, err := io.Copy(rec, r)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("Want %d bytes, got %d.", len(data), n)
+	}
+	if dur < 150*time.Millisecond || dur > 500*time.Millisecond {
+		t.Errorf("Took %s, want 220ms.", dur)
+	}
+}
+
+func TestWriteToBufSize(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name string
+		r    func(src io.Reader) *Reader
+		size int
+		want []int
+	}{
+		// A tenth of a second worth of data.
+		{"bandwidth", func(src io.Reader) *Reader { return NewReader(src, 20000) }, 3000, []int{2000, 1000}},
+		{"hint", func(src io.Reader) *Reader { return NewReaderSize(src, 100000, 1000) }, 3000, []int{1000, 1000, 1000}},
+		{"unlimited", func(src io.Reader) *Reader { return NewReader(src, 0) }, 20000, []int{16 << 10, 20000 - 16<<10}},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := new(recordingWriter)
+			r := testc.r(bytes.NewReader(make([]byte, testc.size)))
+			if _, err := r.WriteTo(rec); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(rec.sizes, testc.want) {
+				t.Errorf("Want chunks %v, got %v.", testc.want, rec.sizes)
+			}
+		})
+	}
+}
+
+func TestDone(t *testing.T) {
+	t.Parallel()
+
+	w := NewWriter(ioutil.Discard, 1000)
+	select {
+	case <-w.Done():
+	default:
+		t.Error("Want Done to be closed before the first write.")
+	}
+
+	// Writing 100 bytes at 1000 B/s sleeps for 100ms.
+	go func() {
+		if _, err := w.Write(make([]byte, 100)); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(20 * time.Millisecond)
+
+	done := w.Done()
+	select {
+	case <-done:
+		t.Error("Want Done to be open during the penalty sleep.")
+	default:
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("Want Done to be closed after the write.")
+	}
+}
+
+func TestNewReaderSize(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name      string
+		hint      int
+		wantStall bool
+	}{
+		// Without hint, the 1 byte buffer adds no compensation.
+		{"none", 0, true},
+		// 1000 bytes at 1000 B/s add 1s of compensation.
+		{"hint", 1000, false},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var stalled bool
+			onReset := WithOnReset(func(reason string) { stalled = reason == ResetStallDetected })
+			r := NewReaderSize(bytes.NewReader(make([]byte, 10)), 1000, testc.hint, WithStallThreshold(50*time.Millisecond), onReset)
+			r.lim.init()
+
+			time.Sleep(100 * time.Millisecond)
+			if _, err := r.Read(make([]byte, 1)); err != nil {
+				t.Fatal(err)
+			}
+			if stalled != testc.wantStall {
+				t.Errorf("Want stall %t, got %t.", testc.wantStall, stalled)
+			}
+		})
+	}
+}
+
+func TestRewrap(t *testing.T) {
+	t.Parallel()
+
+	// Two files of 100 bytes at 1000 B/s take 200ms in total.
+	r := NewReader(bytes.NewReader(nil), 1000)
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 0)
+
+	start := time.Now()
+	for _, file := range []string{"first", "second"} {
+		data := bytes.Repeat([]byte(file[:1]), 100)
+		r.Rewrap(bytes.NewReader(data))
+		if _, err := io.Copy(w, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dur := time.Since(start)
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+	if want := strings.Repeat("f", 100) + strings.Repeat("s", 100); buf.String() != want {
+		t.Errorf("Want %q, got %q.", want, buf.String())
+	}
+
+	var other bytes.Buffer
+	w.Rewrap(&other)
+	if _, err := w.Write([]byte("x")); err != nil {
+		t.Fatal(err)
+	}
+	if other.String() != "x" {
+		t.Errorf("Want %q, got %q.", "x", other.String())
+	}
+}
+
+func TestReset(t *testing.T) {
+	t.Parallel()
+
+	w := NewWriter(ioutil.Discard, 1000)
+	if _, err := w.Write(make([]byte, 50)); err != nil {
+		t.Fatal(err)
+	}
+	w.Reset()
+
+	if s := w.Stats(); s != (Stats{}) {
+		t.Errorf("Want zero stats after reset, got %+v.", s)
+	}
+	if w.lim.isInitialized {
+		t.Error("Want limiter to start from scratch after reset.")
+	}
+
+	// Accounting starts anew with the next write: 100 bytes take 100ms.
+	start := time.Now()
+	if _, err := w.Write(make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if dur := time.Since(start); dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+	if s := w.Stats(); s.BytesTransferred != 100 {
+		t.Errorf("Want 100 bytes, got %d.", s.BytesTransferred)
+	}
+}
+
+// fakeClock is a clock and sleeper for the limiter, whose time only advances
+// by sleeping.
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func (c *fakeClock) Sleep(_ context.Context, d time.Duration) error {
+	c.t = c.t.Add(d)
+	return nil
+}
+
+func TestBandwidthAccuracy(t *testing.T) {
+	t.Parallel()
+
+	const (
+		size      = 10 * MBps
+		bandwidth = MBps
+	)
+
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	r := NewReader(bytes.NewReader(bytes.Repeat([]byte{0}, size)), bandwidth)
+	r.lim.now = clock.now
+	r.lim.sleeper = clock
+
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// The source has no latency, so the transfer takes as long as the
+	// limiter sleeps.
+	s := r.Stats()
+	if s.BytesTransferred != size {
+		t.Errorf("Want %d bytes, got %d.", size, s.BytesTransferred)
+	}
+	rate := float64(s.BytesTransferred) / s.Sleep.Seconds()
+	if rate < 0.95*bandwidth || rate > 1.05*bandwidth {
+		t.Errorf("Want rate within 5%% of %d B/s, got %.0f B/s.", bandwidth, rate)
+	}
+}
+
+func TestCopyContext(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// Copying 10000 bytes at 1000 B/s would take 10s.
+	src := &chunkReader{r: bytes.NewReader(make([]byte, 10000)), size: 10}
+	start := time.Now()
+	n, err := CopyContext(ctx, ioutil.Discard, src, 1000)
+	dur := time.Since(start)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+	if n <= 0 || n >= 10000 {
+		t.Errorf("Want a partial copy, got %d bytes.", n)
+	}
+	if dur > time.Second {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
+
+func TestWriteString(t *testing.T) {
+	t.Parallel()
+
+	var _ io.StringWriter = (*Writer)(nil)
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf, 1000)
+
+	// Writing 100 bytes at 1000 B/s takes 100ms.
+	start := time.Now()
+	n, err := io.WriteString(w, strings.Repeat("x", 100))
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 100 || buf.Len() != 100 {
+		t.Errorf("Want 100 bytes, got %d (%d written).", n, buf.Len())
+	}
+	if s := w.Stats(); s.BytesTransferred != 100 {
+		t.Errorf("Want 100 bytes accounted for, got %d.", s.BytesTransferred)
+	}
+	if dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
+
+func TestSmartCopy(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		bandwidth int
+		want      int
+	}{
+		{0, 16 << 10},
+		{1000, 512},
+		{100 * KBps, 10 * KBps},
+		{100 * MBps, 1 << 20},
+	}
+	for _, testc := range testt {
+		if got := smartBufSize(testc.bandwidth); got != testc.want {
+			t.Errorf("Bandwidth %d: want buffer size %d, got %d.", testc.bandwidth, testc.want, got)
+		}
+	}
+
+	rec := new(recordingWriter)
+	src := struct{ io.Reader }{bytes.NewReader(make([]byte, 30000))}
+	n, err := SmartCopy(rec, src, 100000)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 30000 {
+		t.Errorf("Want 30000 bytes, got %d.", n)
+	}
+	if want := []int{10000, 10000, 10000}; !reflect.DeepEqual(rec.sizes, want) {
+		t.Errorf("Want chunks %v, got %v.", want, rec.sizes)
+	}
+}
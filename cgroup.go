@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// defaultCgroupPath is the mount point of the cgroup v2 unified hierarchy.
+const defaultCgroupPath = "/sys/fs/cgroup"
+
+// WithCgroupBandwidth caps the bandwidth at the I/O bandwidth limit of the
+// cgroup (v2) at cgroupPath, as configured in its io.max file. If cgroupPath
+// is empty, the root of the unified hierarchy at /sys/fs/cgroup is used. The
+// smallest rbps or wbps limit of all devices applies. A bandwidth of zero or
+// less is capped as well.
+//
+// If io.max cannot be read or does not configure any bandwidth limit, the
+// option silently has no effect.
+func WithCgroupBandwidth(cgroupPath string) Option {
+	return func(l *limiter) {
+		dir := cgroupPath
+		if dir == "" {
+			dir = defaultCgroupPath
+		}
+
+		f, err := os.Open(filepath.Join(dir, "io.max"))
+		if err != nil {
+			return
+		}
+		defer f.Close()
+
+		l.maxBandwidth = parseIOMax(f)
+	}
+}
+
+// parseIOMax returns the smallest rbps or wbps limit in the io.max content
+// read from r, or zero if there is no such limit. Each line of io.max
+// configures one device, e.g.
+//
+//	8:16 rbps=2097152 wbps=max riops=max wiops=120
+func parseIOMax(r io.Reader) int {
+	var limit int
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// skip the device number
+		for _, field := range fields[1:] {
+			kv := strings.SplitN(field, "=", 2)
+			if len(kv) != 2 || (kv[0] != "rbps" && kv[0] != "wbps") {
+				continue
+			}
+			bps, err := strconv.Atoi(kv[1])
+			if err != nil || bps <= 0 {
+				// "max" or out of range
+				continue
+			}
+			if limit == 0 || bps < limit {
+				limit = bps
+			}
+		}
+	}
+
+	return limit
+}
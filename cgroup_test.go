@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseIOMax(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name  string
+		ioMax string
+		want  int
+	}{
+		{"empty", "", 0},
+		{"unlimited", "8:0 rbps=max wbps=max riops=max wiops=max\n", 0},
+		{"read", "8:0 rbps=2097152 wbps=max riops=max wiops=120\n", 2097152},
+		{"write", "8:0 rbps=max wbps=1048576 riops=max wiops=max\n", 1048576},
+		{"smallest", "8:0 rbps=4096 wbps=max\n8:16 rbps=max wbps=1024\n", 1024},
+		{"iops only", "8:0 riops=100 wiops=100\n", 0},
+		{"garbage", "8:0 rbps=fast\n", 0},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			if got := parseIOMax(strings.NewReader(testc.ioMax)); got != testc.want {
+				t.Errorf("Want %d, got %d.", testc.want, got)
+			}
+		})
+	}
+}
+
+func TestCgroupBandwidth(t *testing.T) {
+	t.Parallel()
+
+	dir, err := ioutil.TempDir("", "bwio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ioMax := []byte("8:0 rbps=1000 wbps=max riops=max wiops=max\n")
+	if err := ioutil.WriteFile(filepath.Join(dir, "io.max"), ioMax, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	testt := []struct {
+		name      string
+		bandwidth float64
+		path      string
+		want      float64
+	}{
+		{"capped", 2000, dir, 1000},
+		{"below", 500, dir, 500},
+		{"unlimited", 0, dir, 1000},
+		{"missing", 2000, filepath.Join(dir, "missing"), 2000},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			l := newLimiter(testc.bandwidth, []Option{WithCgroupBandwidth(testc.path)})
+			if got := l.getBandwidth(); got != testc.want {
+				t.Errorf("Want bandwidth %f, got %f.", testc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// Chain wraps r in one Reader per bandwidth, i.e. NewReader(NewReader(r,
+// bandwidths[0]), bandwidths[1]) and so on. This models multiple hops with
+// independent limits; the effective rate is the minimum of all bandwidths.
+// Zero or negative bandwidths do not limit and are skipped. Without any
+// limiting bandwidth, Chain returns r unchanged.
+func Chain(r io.Reader, bandwidths ...int) io.Reader {
+	for _, bandwidth := range bandwidths {
+		if bandwidth > 0 {
+			r = NewReader(r, bandwidth)
+		}
+	}
+	return r
+}
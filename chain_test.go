@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	src := bytes.NewReader(nil)
+	if r := Chain(src); r != src {
+		t.Error("Want no wrapper without bandwidths.")
+	}
+	if r, ok := Chain(src, 0, 1000).(*Reader); !ok || r.src != src {
+		t.Error("Want a single Reader for a single limiting bandwidth.")
+	}
+
+	// The effective rate is 1000 B/s, so 100 bytes take 100ms.
+	r := Chain(bytes.NewReader(make([]byte, 100)), 10000, 1000, 5000)
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, r)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 100 {
+		t.Errorf("Want 100 bytes, got %d.", n)
+	}
+	if dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
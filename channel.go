@@ -0,0 +1,156 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	// channelPeriod is the shortest period in which a ChannelWriter
+	// delivers one chunk.
+	channelPeriod = 10 * time.Millisecond
+
+	// maxChannelQueue caps the queue of a ChannelWriter.
+	maxChannelQueue = 1 << 20
+)
+
+// ChannelWriter delivers the data written to it as chunks on a channel at a
+// given bandwidth, one chunk per period of at least 10ms. Below 100 B/s the
+// chunks are single bytes and the period grows accordingly. Instead of
+// sleeping, Write queues the data for a goroutine that paces the sends, so
+// the writer is decoupled from its consumer. The queue holds one second
+// worth of data, but at most 1 MiB; only once it is full does Write block
+// until the consumer catches up.
+type ChannelWriter struct {
+	chunkSize int
+	queueSize int
+	period    time.Duration
+	out       chan []byte
+
+	mu     sync.Mutex
+	cond   *sync.Cond // signals changes of queue and closed
+	queue  []byte
+	closed bool
+}
+
+// NewChannelWriter returns a new ChannelWriter that maintains the given
+// bandwidth and the channel its chunks are delivered on. The consumer must
+// receive from the channel until it is closed, which happens after Close
+// once all data has been delivered. If bandwidth is zero or negative, the
+// ChannelWriter will not limit and delivers the data as soon as the consumer
+// receives.
+func NewChannelWriter(bandwidth int) (*ChannelWriter, <-chan []byte) {
+	cw := &ChannelWriter{
+		chunkSize: defaultBufSize,
+		queueSize: defaultBufSize,
+		out:       make(chan []byte),
+	}
+	if bandwidth > 0 {
+		cw.chunkSize = int(math.Ceil(float64(bandwidth) * channelPeriod.Seconds()))
+		if cw.chunkSize > maxChannelQueue {
+			cw.chunkSize = maxChannelQueue
+		}
+		// The period follows from the rounded chunk size, so the rate is
+		// exact even if a chunk is worth more than 10ms.
+		cw.period = time.Duration(cw.chunkSize) * time.Second / time.Duration(bandwidth)
+		cw.queueSize = bandwidth
+		if cw.queueSize > maxChannelQueue {
+			cw.queueSize = maxChannelQueue
+		}
+	}
+	cw.cond = sync.NewCond(&cw.mu)
+
+	go cw.pace()
+	return cw, cw.out
+}
+
+// Write implements the io.Writer interface. It queues a copy of p for
+// delivery and returns without waiting for it, unless the queue is full.
+// After Close, Write fails with io.ErrClosedPipe.
+func (cw *ChannelWriter) Write(p []byte) (n int, err error) {
+	cw.mu.Lock()
+	defer cw.mu.Unlock()
+
+	for n < len(p) {
+		for !cw.closed && len(cw.queue) >= cw.queueSize {
+			cw.cond.Wait()
+		}
+		if cw.closed {
+			return n, io.ErrClosedPipe
+		}
+
+		m := len(p) - n
+		if room := cw.queueSize - len(cw.queue); m > room {
+			m = room
+		}
+		cw.queue = append(cw.queue, p[n:n+m]...)
+		n += m
+		cw.cond.Broadcast()
+	}
+	return n, nil
+}
+
+// Close stops accepting writes. The data queued so far is still delivered,
+// then the channel is closed.
+func (cw *ChannelWriter) Close() error {
+	cw.mu.Lock()
+	cw.closed = true
+	cw.cond.Broadcast()
+	cw.mu.Unlock()
+	return nil
+}
+
+// pace sends the queued data in chunks, one per period if limited, until the
+// ChannelWriter is closed and the queue is empty.
+func (cw *ChannelWriter) pace() {
+	defer close(cw.out)
+
+	var tick <-chan time.Time
+	if cw.period > 0 {
+		ticker := time.NewTicker(cw.period)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	for {
+		cw.mu.Lock()
+		for !cw.closed && len(cw.queue) == 0 {
+			cw.cond.Wait()
+		}
+		if len(cw.queue) == 0 {
+			cw.mu.Unlock()
+			return
+		}
+		n := len(cw.queue)
+		if n > cw.chunkSize {
+			n = cw.chunkSize
+		}
+		chunk := append([]byte(nil), cw.queue[:n]...)
+		cw.queue = cw.queue[n:]
+		cw.cond.Broadcast()
+		cw.mu.Unlock()
+
+		cw.out <- chunk
+		if tick != nil {
+			<-tick
+		}
+	}
+}
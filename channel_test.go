@@ -0,0 +1,170 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestChannelWriter(t *testing.T) {
+	t.Parallel()
+
+	cw, ch := NewChannelWriter(10000)
+	data := make([]byte, 3000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	// The queue holds a second worth of data, so Write does not wait for
+	// the consumer.
+	start := time.Now()
+	if _, err := cw.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if dur := time.Since(start); dur > 50*time.Millisecond {
+		t.Errorf("Write took %s, want it to return immediately.", dur)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 3000 bytes at 10000 B/s are 30 chunks of 100 bytes, one per 10ms.
+	var got []byte
+	var chunks int
+	for chunk := range ch {
+		if len(chunk) > 100 {
+			t.Errorf("Want chunks of at most 100 bytes, got %d.", len(chunk))
+		}
+		got = append(got, chunk...)
+		chunks++
+	}
+	dur := time.Since(start)
+
+	if chunks != 30 {
+		t.Errorf("Want 30 chunks, got %d.", chunks)
+	}
+	if !bytes.Equal(got, data) {
+		t.Error("Received data differs from written data.")
+	}
+	if dur < 250*time.Millisecond || dur > 500*time.Millisecond {
+		t.Errorf("Took %s, want 300ms.", dur)
+	}
+}
+
+func TestChannelWriterUnlimited(t *testing.T) {
+	t.Parallel()
+
+	cw, ch := NewChannelWriter(0)
+
+	go func() {
+		defer cw.Close()
+		if _, err := cw.Write([]byte("hello")); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	var got []byte
+	for chunk := range ch {
+		got = append(got, chunk...)
+	}
+	if string(got) != "hello" {
+		t.Errorf("Want %q, got %q.", "hello", got)
+	}
+}
+
+func TestChannelWriterClose(t *testing.T) {
+	t.Parallel()
+
+	cw, _ := NewChannelWriter(100)
+
+	// Nobody receives, so Write blocks on the full queue until Close.
+	errc := make(chan error, 1)
+	go func() {
+		_, err := cw.Write(make([]byte, 300))
+		errc <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-errc; !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Want %v, got %v.", io.ErrClosedPipe, err)
+	}
+	if _, err := cw.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Errorf("Want %v after close, got %v.", io.ErrClosedPipe, err)
+	}
+}
+
+func TestChannelWriterSlow(t *testing.T) {
+	t.Parallel()
+
+	cw, ch := NewChannelWriter(20)
+	if _, err := cw.Write(make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// At 20 B/s the chunks are single bytes, one per 50ms. The first one
+	// goes out immediately.
+	start := time.Now()
+	var n int
+	for chunk := range ch {
+		n += len(chunk)
+	}
+	dur := time.Since(start)
+
+	if n != 10 {
+		t.Errorf("Want 10 bytes, got %d.", n)
+	}
+	if dur < 400*time.Millisecond || dur > 700*time.Millisecond {
+		t.Errorf("Took %s, want 450ms.", dur)
+	}
+}
+
+func TestChannelWriterSizes(t *testing.T) {
+	t.Parallel()
+
+	type testc struct {
+		bandwidth int
+		chunkSize int
+		queueSize int
+		period    time.Duration
+	}
+	for _, c := range []testc{
+		{bandwidth: 10, chunkSize: 1, queueSize: 10, period: 100 * time.Millisecond},
+		{bandwidth: 150, chunkSize: 2, queueSize: 150, period: 2 * time.Second / 150},
+		{bandwidth: 10000, chunkSize: 100, queueSize: 10000, period: 10 * time.Millisecond},
+		{bandwidth: 1 << 30, chunkSize: maxChannelQueue, queueSize: maxChannelQueue, period: time.Second / (1 << 10)},
+		{bandwidth: 0, chunkSize: defaultBufSize, queueSize: defaultBufSize},
+	} {
+		cw, _ := NewChannelWriter(c.bandwidth)
+		if err := cw.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if cw.chunkSize != c.chunkSize || cw.queueSize != c.queueSize || cw.period != c.period {
+			t.Errorf("Bandwidth %d: want chunks of %d, a queue of %d and a period of %s, got %d, %d and %s.",
+				c.bandwidth, c.chunkSize, c.queueSize, c.period, cw.chunkSize, cw.queueSize, cw.period)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// ChunkedWriter wraps another writer, maintains a given bandwidth and writes
+// at most a maximum number of bytes at once. Larger writes are split into
+// chunks, each of which is written and limited on its own.
+type ChunkedWriter struct {
+	Writer
+}
+
+// NewChunkedWriter returns a new ChunkedWriter that wraps w, writes at most
+// maxChunk bytes at once and maintains the given bandwidth. A write of
+// len(p) bytes results in ceil(len(p)/maxChunk) writes to w. If maxChunk is
+// zero or negative, writes are not split. If bandwidth is zero or negative,
+// the ChunkedWriter will not limit.
+func NewChunkedWriter(w io.Writer, maxChunk, bandwidth int, opts ...Option) *ChunkedWriter {
+	return &ChunkedWriter{
+		Writer: Writer{
+			dst: w,
+			lim: newLimiter(float64(bandwidth), opts),
+			mtu: maxChunk,
+		},
+	}
+}
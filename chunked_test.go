@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestChunkedWriter(t *testing.T) {
+	t.Parallel()
+
+	rec := new(recordingWriter)
+	cw := NewChunkedWriter(rec, 100, 1000)
+
+	// Writing 250 bytes at 1000 B/s takes 250ms.
+	start := time.Now()
+	n, err := cw.Write(make([]byte, 250))
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 250 {
+		t.Errorf("Want 250 bytes, got %d.", n)
+	}
+	if want := []int{100, 100, 50}; !reflect.DeepEqual(rec.sizes, want) {
+		t.Errorf("Want chunks %v, got %v.", want, rec.sizes)
+	}
+	if dur < 200*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 250ms.", dur)
+	}
+}
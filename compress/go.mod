@@ -0,0 +1,8 @@
+module github.com/jwkohnen/bwio/compress
+
+go 1.26.0
+
+require (
+	github.com/jwkohnen/bwio v0.1.0
+	github.com/klauspost/compress v1.20.1
+)
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package compress
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/klauspost/compress/zstd"
+)
+
+func TestZstdDecoder(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Takes 10s.")
+	}
+	t.Parallel()
+
+	// Random data does not compress, so the compressed stream is about
+	// 10 MiB, too.
+	const size = 10 * bwio.MBps
+	data := make([]byte, size)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	var compressed bytes.Buffer
+	enc, err := zstd.NewWriter(&compressed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := enc.Write(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	dec, err := zstd.NewReader(bwio.NewReader(&compressed, bwio.MBps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dec.Close()
+
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, dec)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != size {
+		t.Errorf("Want %d bytes, got %d.", size, n)
+	}
+	if dur < 9*time.Second || dur > 12*time.Second {
+		t.Errorf("Took %s, want 10s.", dur)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"errors"
+	"net"
+)
+
+// Conn wraps a net.Conn and maintains separate bandwidths for reading and
+// writing. All other methods are those of the wrapped connection. Unlike
+// Reader and Writer, Conn returns the errors of the wrapped connection as
+// they are rather than as *BwioError, as the net.Conn contract requires; a
+// deadline error, for example, still implements net.Error.
+type Conn struct {
+	net.Conn
+	r *Reader
+	w *Writer
+}
+
+// NewConn returns a new Conn that wraps conn and maintains readBandwidth for
+// reads and writeBandwidth for writes. The options apply to both directions,
+// each of which has its own limiter. If a bandwidth is zero or negative, the
+// Conn will not limit that direction.
+func NewConn(conn net.Conn, readBandwidth, writeBandwidth int, opts ...Option) *Conn {
+	return &Conn{
+		Conn: conn,
+		r:    NewReader(conn, readBandwidth, opts...),
+		w:    NewWriter(conn, writeBandwidth, opts...),
+	}
+}
+
+// Read implements the io.Reader interface and maintains the read bandwidth.
+func (c *Conn) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	return n, connError(err)
+}
+
+// Write implements the io.Writer interface and maintains the write
+// bandwidth.
+func (c *Conn) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	return n, connError(err)
+}
+
+// connError returns the error of the wrapped connection from err.
+func connError(err error) error {
+	var bwErr *BwioError
+	if errors.As(err, &bwErr) {
+		return bwErr.Cause
+	}
+	return err
+}
+
+// SetReadBandwidth changes the read bandwidth of the Conn. It is safe to call
+// SetReadBandwidth concurrently with Read.
+func (c *Conn) SetReadBandwidth(bandwidth int) {
+	c.r.SetBandwidth(bandwidth)
+}
+
+// SetWriteBandwidth changes the write bandwidth of the Conn. It is safe to
+// call SetWriteBandwidth concurrently with Write.
+func (c *Conn) SetWriteBandwidth(bandwidth int) {
+	c.w.SetBandwidth(bandwidth)
+}
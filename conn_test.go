@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestTCPBandwidthLimiting does not run in parallel, because it expects the
+// transfer time within 10%.
+func TestTCPBandwidthLimiting(t *testing.T) {
+	const (
+		size     = 50 << 10
+		readBW   = 100 * KBps
+		wantTime = time.Duration(size) * time.Second / readBW
+	)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		if _, err := conn.Write(make([]byte, size)); err != nil {
+			t.Error(err)
+		}
+	}()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewConn(conn, readBW, 0)
+	defer c.Close()
+
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, c)
+	dur := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != size {
+		t.Errorf("Want %d bytes, got %d.", size, n)
+	}
+	if dur < wantTime*9/10 || dur > wantTime*11/10 {
+		t.Errorf("Took %s, want %s.", dur, wantTime)
+	}
+}
+
+func TestConnWrite(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	c := NewConn(client, 0, 1000)
+	defer c.Close()
+
+	go func() {
+		_, _ = io.Copy(ioutil.Discard, server)
+	}()
+
+	// Writing 200 bytes at 1000 B/s takes 200ms.
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := c.Write(make([]byte, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dur := time.Since(start); dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestConnDeadline(t *testing.T) {
+	t.Parallel()
+
+	client, server := net.Pipe()
+	defer server.Close()
+	c := NewConn(client, 1000, 1000)
+	defer c.Close()
+
+	if err := c.SetReadDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	_, err := c.Read(make([]byte, 10))
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Want a net.Error that times out, got %#v.", err)
+	}
+	if !os.IsTimeout(err) {
+		t.Errorf("Want os.IsTimeout to be true for %v.", err)
+	}
+
+	if err := c.SetWriteDeadline(time.Now().Add(10 * time.Millisecond)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Write(make([]byte, 10)); !os.IsTimeout(err) {
+		t.Errorf("Want os.IsTimeout to be true for %v.", err)
+	}
+}
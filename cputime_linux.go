@@ -0,0 +1,46 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "time"
+
+// WithCPUTimeMode makes the limiter measure time as the CPU time consumed by
+// the process (CLOCK_PROCESS_CPUTIME_ID) instead of the wall clock. The
+// bandwidth is then maintained in bytes per CPU second, which throttles
+// compute-bound transfers like compression in CPU-constrained environments.
+// Note that penalty sleeps do not consume CPU time; if the process is mostly
+// idle, the transfer slows down accordingly. The option applies to the
+// default bucket strategy, not to WithLeakyBucket or WithSlidingWindow. It is
+// only effective on Linux and has no effect if the clock is not available.
+func WithCPUTimeMode() Option {
+	return func(l *limiter) {
+		if _, ok := clockGettime(clockProcessCPUTimeID); ok {
+			l.now = nowProcessCPUTime
+			l.cpuTime = true
+		}
+	}
+}
+
+// nowProcessCPUTime returns the CPU time consumed by the process as a time
+// relative to the zero Unix time.
+func nowProcessCPUTime() time.Time {
+	t, _ := clockGettime(clockProcessCPUTimeID)
+	return t
+}
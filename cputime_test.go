@@ -0,0 +1,78 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// TestCPUTimeMode does not run in parallel, because other tests would
+// consume CPU time of the process.
+func TestCPUTimeMode(t *testing.T) {
+	l := newLimiter(1000, []Option{WithCPUTimeMode()})
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU time mode is only effective on Linux.")
+	}
+
+	// Sleeping does not consume CPU time.
+	t1 := l.now()
+	time.Sleep(50 * time.Millisecond)
+	if d := l.now().Sub(t1); d > 25*time.Millisecond {
+		t.Errorf("Sleeping consumed %s of CPU time, want ~0s.", d)
+	}
+
+	// Busy waiting does.
+	t1 = l.now()
+	for deadline := time.Now().Add(50 * time.Millisecond); time.Now().Before(deadline); {
+	}
+	if d := l.now().Sub(t1); d < 25*time.Millisecond {
+		t.Errorf("Busy waiting consumed %s of CPU time, want 50ms.", d)
+	}
+}
+
+// TestCPUTimeModePenalties does not run in parallel for the same reason as
+// TestCPUTimeMode.
+func TestCPUTimeModePenalties(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("CPU time mode is only effective on Linux.")
+	}
+
+	// Reading 10 KB at 10 KB/s in reads of 1 KB sleeps for 100ms each,
+	// not for all previous penalties again, since sleeping does not
+	// advance the CPU time.
+	var sleeps []time.Duration
+	l := newLimiter(10000, []Option{WithCPUTimeMode()})
+	l.sleeper = sleepFunc(func(d time.Duration) { sleeps = append(sleeps, d) })
+	l.init()
+
+	for i := 0; i < 10; i++ {
+		if _, err := l.limit(1000, 1000); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if len(sleeps) != 10 {
+		t.Fatalf("Want 10 sleeps, got %v.", sleeps)
+	}
+	for i, d := range sleeps {
+		if d < 80*time.Millisecond || d > 100*time.Millisecond {
+			t.Errorf("Sleep %d: want 100ms, got %s.", i, d)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// WithDeadlineProportional adjusts the bandwidth before each operation such
+// that the transfer finishes right at the deadline of the context set by
+// WithContext, i.e. to the bytes remaining of WithTotalSize divided by the
+// time remaining. The option has no effect without both a deadline and a
+// total size. Once the deadline has passed, the limiter does not limit.
+func WithDeadlineProportional() Option {
+	return func(l *limiter) { l.deadlineProportional = true }
+}
+
+// adjustToDeadline sets the bandwidth to the rate that transfers the
+// remaining bytes in the time left until the deadline. The deadline is a wall
+// clock time, so the time left is measured with time.Until regardless of the
+// clock of the limiter.
+func (l *limiter) adjustToDeadline() {
+	if !l.deadlineProportional || l.totalSize <= 0 {
+		return
+	}
+	deadline, ok := l.ctx.Deadline()
+	if !ok {
+		return
+	}
+
+	remaining := l.totalSize - atomic.LoadInt64(&l.transferred)
+	if remaining <= 0 {
+		return
+	}
+	left := time.Until(deadline)
+	if left <= 0 {
+		l.setBandwidth(0)
+		return
+	}
+	l.setBandwidth(float64(remaining) / left.Seconds())
+}
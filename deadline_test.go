@@ -0,0 +1,86 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// deadlineContext reports a deadline, but is never done, such that a late
+// last operation does not fail the test.
+type deadlineContext struct {
+	context.Context
+	deadline time.Time
+}
+
+func (c deadlineContext) Deadline() (time.Time, bool) {
+	return c.deadline, true
+}
+
+// withUptimeClock makes the limiter measure time since the zero time plus the
+// uptime of the test binary, like WithMonotonicRaw or WithCPUTimeMode, whose
+// times are unrelated to the wall clock.
+func withUptimeClock() Option {
+	start := time.Now()
+	return func(l *limiter) {
+		l.now = func() time.Time { return time.Time{}.Add(time.Since(start)) }
+	}
+}
+
+func TestDeadlineProportional(t *testing.T) {
+	t.Parallel()
+
+	const size = 2000
+
+	testt := []struct {
+		name    string
+		opts    []Option
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		// 2000 bytes at 10 KBps are done in about 200ms.
+		{"without", nil, 150 * time.Millisecond, 250 * time.Millisecond},
+		{"proportional", []Option{WithDeadlineProportional(), WithTotalSize(size)}, 350 * time.Millisecond, 450 * time.Millisecond},
+		{"no total size", []Option{WithDeadlineProportional()}, 150 * time.Millisecond, 250 * time.Millisecond},
+		{"uptime clock", []Option{WithDeadlineProportional(), WithTotalSize(size), withUptimeClock()}, 350 * time.Millisecond, 450 * time.Millisecond},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			start := time.Now()
+			ctx := deadlineContext{context.Background(), start.Add(400 * time.Millisecond)}
+			opts := append([]Option{WithContext(ctx)}, testc.opts...)
+			r := NewReader(bytes.NewReader(make([]byte, size)), 10000, opts...)
+
+			_, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 100))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if took := time.Since(start); took < testc.wantMin || took > testc.wantMax {
+				t.Errorf("Took %s, want %s..%s.", took, testc.wantMin, testc.wantMax)
+			}
+		})
+	}
+}
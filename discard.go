@@ -0,0 +1,38 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io/ioutil"
+
+// DiscardWriter is an io.Writer that discards all data, but maintains a given
+// bandwidth as if the data was written somewhere. It simulates a slow
+// consumer, e.g. a slow network peer, without a real destination.
+type DiscardWriter struct {
+	Writer
+}
+
+// NewDiscardWriter returns a new DiscardWriter that maintains the given
+// bandwidth. If bandwidth is zero or negative, the DiscardWriter will not
+// limit.
+func NewDiscardWriter(bandwidth int, opts ...Option) *DiscardWriter {
+	return &DiscardWriter{
+		Writer: Writer{
+			dst: ioutil.Discard,
+			lim: newLimiter(float64(bandwidth), opts),
+		},
+	}
+}
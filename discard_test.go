@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestDiscardWriter(t *testing.T) {
+	t.Parallel()
+
+	dw := NewDiscardWriter(1000)
+	src := &chunkReader{r: bytes.NewReader(make([]byte, 200)), size: 50}
+
+	start := time.Now()
+	n, err := io.Copy(dw, src)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 200 {
+		t.Errorf("Want 200 bytes, got %d.", n)
+	}
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
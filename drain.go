@@ -0,0 +1,80 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// debt returns the time the limiter owes, i.e. the penalties it has deferred
+// or not yet slept for, e.g. due to WithMinSleep or WithSlack. It is zero if
+// the limiter is ahead of the bandwidth.
+func (l *limiter) debt(now time.Time) time.Duration {
+	bandwidth := l.getBandwidth()
+	if !l.isInitialized || bandwidth <= 0 {
+		return 0
+	}
+
+	var debt time.Duration
+	if l.leaky {
+		debt = l.drained.Sub(now)
+	} else {
+		debt = time.Duration(float64(l.bucket)*float64(time.Second)/bandwidth) - now.Sub(l.start)
+	}
+	if debt < 0 {
+		return 0
+	}
+	return debt
+}
+
+// drain sleeps for the debt of the limiter and starts a new bucket.
+func (l *limiter) drain(ctx context.Context) error {
+	if debt := l.debt(l.now()); debt > 0 {
+		start := l.now()
+		if err := l.sleeper.Sleep(ctx, debt); err != nil {
+			return err
+		}
+		atomic.AddInt64(&l.sleptRequested, int64(debt))
+		atomic.AddInt64(&l.sleptActual, int64(l.now().Sub(start)))
+	}
+
+	if l.isInitialized {
+		l.reset()
+		l.drained = time.Time{}
+	}
+	return nil
+}
+
+// Drain sleeps for the time the Reader currently owes to maintain the
+// bandwidth and then starts a new bucket, which discards any credit as well.
+// It does not read. This synchronizes the limiter with the wall clock, e.g.
+// before the Reader is handed off or rewrapped for another stream, without
+// clearing the stats like Reset. Drain returns ctx.Err(), if ctx is done
+// before the debt has been paid. Drain must not be called concurrently with
+// Read.
+func (r *Reader) Drain(ctx context.Context) error {
+	return r.lim.drain(ctx)
+}
+
+// Drain sleeps for the time the Writer currently owes to maintain the
+// bandwidth and then starts a new bucket, see Reader.Drain. It does not
+// write. Drain must not be called concurrently with Write.
+func (w *Writer) Drain(ctx context.Context) error {
+	return w.lim.drain(ctx)
+}
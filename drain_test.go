@@ -0,0 +1,95 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestDrain(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name string
+		opts []Option
+	}{
+		{"bucket", []Option{WithMinSleep(time.Hour)}},
+		{"leaky", []Option{WithLeakyBucket()}},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			// Neither the deferred penalty nor the leaky bucket sleep
+			// after the first write, so the limiter owes 200ms at
+			// 1000 B/s.
+			w := NewWriter(ioutil.Discard, 1000, testc.opts...)
+			if _, err := w.Write(make([]byte, 200)); err != nil {
+				t.Fatal(err)
+			}
+
+			start := time.Now()
+			if err := w.Drain(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if dur := time.Since(start); dur < 150*time.Millisecond || dur > 300*time.Millisecond {
+				t.Errorf("Took %s, want 200ms.", dur)
+			}
+
+			// The debt has been paid.
+			start = time.Now()
+			if err := w.Drain(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			if dur := time.Since(start); dur > 20*time.Millisecond {
+				t.Errorf("Took %s on the second drain, want no sleep.", dur)
+			}
+		})
+	}
+}
+
+func TestDrainContext(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 100, WithMinSleep(time.Hour))
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reading 1000 bytes at 100 B/s owes 10s.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := r.Drain(ctx); err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestDrainUnused(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(nil), 1000)
+	if err := r.Drain(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,46 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"os"
+)
+
+// BandwidthFromEnv returns the bandwidth configured in the environment
+// variable envVar, as parsed by ParseBandwidth. If the variable is not set or
+// empty, it returns zero, i.e. unlimited.
+func BandwidthFromEnv(envVar string) (int, error) {
+	s := os.Getenv(envVar)
+	if s == "" {
+		return 0, nil
+	}
+	return ParseBandwidth(s)
+}
+
+// NewReaderEnv returns a new reader that wraps reader r and maintains the
+// bandwidth configured in the environment variable envVar, e.g. "512KB/s". If
+// the variable is not set or empty, the Reader will not limit. NewReaderEnv
+// panics if the variable cannot be parsed; use BandwidthFromEnv and NewReader
+// to handle the error instead.
+func NewReaderEnv(r io.Reader, envVar string, opts ...Option) *Reader {
+	bandwidth, err := BandwidthFromEnv(envVar)
+	if err != nil {
+		panic(err)
+	}
+	return NewReader(r, bandwidth, opts...)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestNewReaderEnv(t *testing.T) {
+	const envVar = "BWIO_TEST_NEW_READER_ENV"
+
+	testt := []struct {
+		name  string
+		value *string
+		want  float64
+	}{
+		{"unset", nil, 0},
+		{"empty", new(string), 0},
+		{"set", func() *string { s := "512KB/s"; return &s }(), 512 * KBps},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			if testc.value != nil {
+				os.Setenv(envVar, *testc.value)
+			} else {
+				os.Unsetenv(envVar)
+			}
+			defer os.Unsetenv(envVar)
+
+			r := NewReaderEnv(bytes.NewReader(nil), envVar)
+			if got := r.lim.getBandwidth(); got != testc.want {
+				t.Errorf("Want bandwidth %f, got %f.", testc.want, got)
+			}
+		})
+	}
+}
+
+func TestNewReaderEnvPanic(t *testing.T) {
+	const envVar = "BWIO_TEST_NEW_READER_ENV_PANIC"
+
+	os.Setenv(envVar, "fast")
+	defer os.Unsetenv(envVar)
+
+	if _, err := BandwidthFromEnv(envVar); err == nil {
+		t.Error("Want error, got nil.")
+	}
+
+	defer func() {
+		if r := recover(); r == nil {
+			t.Error("Should have panicked, but did not.")
+		}
+	}()
+	NewReaderEnv(bytes.NewReader(nil), envVar)
+}
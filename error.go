@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"fmt"
+	"io"
+)
+
+// BwioError wraps an error of the underlying reader or writer together with
+// the state of the limiter at the time of the error, so that callers can log
+// the bandwidth context alongside the error. Reader and Writer return it for
+// all errors of the wrapped stream except io.EOF, which callers compare
+// against directly. Use errors.Is or errors.As to inspect the cause.
+type BwioError struct {
+	// Cause is the error of the underlying reader or writer.
+	Cause error
+	// ConfiguredBandwidth is the bandwidth at the time of the error.
+	ConfiguredBandwidth int
+	// ActualRate is the rate achieved since the first operation in bytes
+	// per second.
+	ActualRate float64
+	// BytesTransferred is the number of bytes transferred, including those
+	// of the failing operation.
+	BytesTransferred int64
+}
+
+func (e *BwioError) Error() string {
+	return fmt.Sprintf("%v (bwio: bandwidth %d B/s, rate %.0f B/s, %d bytes transferred)",
+		e.Cause, e.ConfiguredBandwidth, e.ActualRate, e.BytesTransferred)
+}
+
+// Unwrap returns the cause.
+func (e *BwioError) Unwrap() error {
+	return e.Cause
+}
+
+// wrapError wraps err of the underlying stream in a BwioError. It leaves nil
+// and io.EOF as they are.
+func (l *limiter) wrapError(err error) error {
+	if err == nil || err == io.EOF {
+		return err
+	}
+
+	stats := l.stats()
+	return &BwioError{
+		Cause:               err,
+		ConfiguredBandwidth: l.bandwidthInt(),
+		ActualRate:          stats.AchievedBandwidth,
+		BytesTransferred:    stats.BytesTransferred,
+	}
+}
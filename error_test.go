@@ -0,0 +1,51 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func TestBwioError(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(io.MultiReader(bytes.NewReader(make([]byte, 100)), new(pReader)), 1<<20)
+	_, err := io.Copy(ioutil.Discard, r)
+
+	var bwErr *BwioError
+	if !errors.As(err, &bwErr) {
+		t.Fatalf("Want *BwioError, got %T.", err)
+	}
+	if !errors.Is(err, errPoison) {
+		t.Errorf("Want cause %v, got %v.", errPoison, bwErr.Cause)
+	}
+	if bwErr.ConfiguredBandwidth != 1<<20 {
+		t.Errorf("Want bandwidth %d, got %d.", 1<<20, bwErr.ConfiguredBandwidth)
+	}
+	if bwErr.BytesTransferred != 100 {
+		t.Errorf("Want 100 bytes, got %d.", bwErr.BytesTransferred)
+	}
+
+	// io.EOF is never wrapped.
+	if _, err := NewReader(bytes.NewReader(nil), 0).Read(make([]byte, 1)); err != io.EOF {
+		t.Errorf("Want %v, got %v.", io.EOF, err)
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/jwkohnen/bwio"
+)
+
+func ExampleNewReader() {
+	// Read at most 10 KBps from a source of 2 KiB, which takes 200ms.
+	src := io.LimitReader(strings.NewReader(strings.Repeat("x", 4<<10)), 2<<10)
+	r := bwio.NewReader(src, 10*bwio.KBps)
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(len(data), "bytes read")
+	// Output: 2048 bytes read
+}
+
+func ExampleNewWriter() {
+	// Write "hello, world" at 100 bytes per second, which takes 120ms.
+	var buf bytes.Buffer
+	w := bwio.NewWriter(&buf, 100)
+
+	if _, err := io.WriteString(w, "hello, world"); err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(buf.String())
+	// Output: hello, world
+}
+
+func ExampleCopy() {
+	// Copy the first 19 bytes of a longer text to stdout at 190 bytes per
+	// second, which takes 100ms.
+	src := io.LimitReader(strings.NewReader("The quick brown fox jumps over the lazy dog.\n"), 19)
+
+	n, err := bwio.Copy(os.Stdout, src, 190)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println()
+	fmt.Println(n, "bytes copied")
+	// Output:
+	// The quick brown fox
+	// 19 bytes copied
+}
+
+func ExamplePool() {
+	// Two downloads share 20 KBps, while each one may use all of it.
+	p := bwio.NewPool(20 * bwio.KBps)
+
+	var wg sync.WaitGroup
+	sizes := make([]int64, 2)
+	for i := range sizes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			src := io.LimitReader(zeroes{}, 2<<10)
+			r := bwio.NewReader(src, 0, bwio.WithPool(p))
+			sizes[i], _ = io.Copy(ioutil.Discard, r)
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Println(sizes[0]+sizes[1], "bytes downloaded")
+	// Output: 4096 bytes downloaded
+}
+
+// zeroes is an endless source of zero bytes.
+type zeroes struct{}
+
+func (zeroes) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = 0
+	}
+	return len(p), nil
+}
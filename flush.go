@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// CopyFlush copies the same way Copy does and flushes dst whenever
+// flushInterval has passed since the last flush, and once more at the end of
+// the copy. This suits destinations like bufio.Writer or HTTP responses that
+// must flush periodically to prevent client timeouts. The interval is
+// checked after each buffer sized chunk. If flushInterval is zero or
+// negative, dst is flushed after each chunk.
+//
+// All bytes count toward the bandwidth when they are copied, regardless of
+// when they are flushed.
+func CopyFlush(dst interface {
+	io.Writer
+	Flush() error
+}, src io.Reader, bandwidth int, flushInterval time.Duration) (written int64, err error) {
+	fw := &flushWriter{
+		dst:       dst,
+		interval:  flushInterval,
+		lastFlush: time.Now(),
+	}
+
+	written, err = CopyBuffer(fw, src, bandwidth, nil)
+	if err != nil {
+		return written, err
+	}
+
+	return written, dst.Flush()
+}
+
+// flushWriter flushes its destination periodically.
+type flushWriter struct {
+	dst interface {
+		io.Writer
+		Flush() error
+	}
+	interval  time.Duration
+	lastFlush time.Time
+}
+
+func (w *flushWriter) Write(p []byte) (n int, err error) {
+	n, err = w.dst.Write(p)
+	if err != nil {
+		return n, err
+	}
+
+	if now := time.Now(); now.Sub(w.lastFlush) >= w.interval {
+		w.lastFlush = now
+		return n, w.dst.Flush()
+	}
+
+	return n, nil
+}
+
+// flusher is implemented by buffered writers like bufio.Writer.
+type flusher interface {
+	Flush() error
+}
+
+// WithFlushOnSleep flushes f before each penalty sleep, so that a buffered
+// writer downstream does not hold back data for the whole sleep. A failing
+// Flush fails the operation that would have slept. Writer.FlushCount returns
+// the number of these flushes.
+func WithFlushOnSleep(f interface{ Flush() error }) Option {
+	return func(l *limiter) { l.flusher = f }
+}
+
+// flush flushes the flusher of WithFlushOnSleep, if any.
+func (l *limiter) flush() error {
+	if l.flusher == nil {
+		return nil
+	}
+	atomic.AddInt64(&l.flushes, 1)
+	return l.flusher.Flush()
+}
+
+// FlushCount returns the number of flushes forced by the penalty sleeps of
+// the Writer, see WithFlushOnSleep. It is safe to call FlushCount
+// concurrently with Write.
+func (w *Writer) FlushCount() int64 {
+	return atomic.LoadInt64(&w.lim.flushes)
+}
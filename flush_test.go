@@ -0,0 +1,124 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// chunkReader reads at most size bytes at a time.
+type chunkReader struct {
+	r    io.Reader
+	size int
+}
+
+func (r *chunkReader) Read(p []byte) (int, error) {
+	if len(p) > r.size {
+		p = p[:r.size]
+	}
+	return r.r.Read(p)
+}
+
+// countingFlusher counts the flushes of a bufio.Writer.
+type countingFlusher struct {
+	*bufio.Writer
+	flushes int
+	err     error
+}
+
+func (f *countingFlusher) Flush() error {
+	f.flushes++
+	if f.err != nil {
+		return f.err
+	}
+	return f.Writer.Flush()
+}
+
+func TestCopyFlush(t *testing.T) {
+	t.Parallel()
+
+	data := bytes.Repeat([]byte("0123456789"), 20)
+	var buf bytes.Buffer
+	dst := &countingFlusher{Writer: bufio.NewWriter(&buf)}
+	src := &chunkReader{r: bytes.NewReader(data), size: 20}
+
+	// Ten chunks at 20ms each, flushed every 50ms and at the end.
+	n, err := CopyFlush(dst, src, 1000, 50*time.Millisecond)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != int64(len(data)) {
+		t.Errorf("Want %d bytes, got %d.", len(data), n)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("Want all data to be flushed, got %d bytes.", buf.Len())
+	}
+	if dst.flushes < 3 || dst.flushes > 6 {
+		t.Errorf("Want about 4 flushes, got %d.", dst.flushes)
+	}
+}
+
+func TestCopyFlushError(t *testing.T) {
+	t.Parallel()
+
+	dst := &countingFlusher{Writer: bufio.NewWriter(new(bytes.Buffer)), err: errPoison}
+	src := &chunkReader{r: bytes.NewReader(make([]byte, 100)), size: 20}
+
+	_, err := CopyFlush(dst, src, 0, 0)
+	if err != errPoison {
+		t.Errorf("Want %v, got %v.", errPoison, err)
+	}
+	if dst.flushes != 1 {
+		t.Errorf("Want to stop after the first failed flush, got %d flushes.", dst.flushes)
+	}
+}
+
+func TestFlushOnSleep(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	bw := &countingFlusher{Writer: bufio.NewWriter(&buf)}
+
+	// Each write of 100 bytes at 2000 B/s sleeps for 50ms, during which the
+	// written data must not wait in the buffer.
+	w := NewWriter(bw, 2000, WithFlushOnSleep(bw))
+	for i := 0; i < 3; i++ {
+		if _, err := w.Write(make([]byte, 100)); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != (i+1)*100 {
+			t.Errorf("Want %d bytes flushed, got %d.", (i+1)*100, buf.Len())
+		}
+	}
+	if got := w.FlushCount(); got != 3 {
+		t.Errorf("Want 3 flushes, got %d.", got)
+	}
+	if bw.flushes != 3 {
+		t.Errorf("Want 3 calls of Flush, got %d.", bw.flushes)
+	}
+
+	errFlush := errors.New("flush failed")
+	bw.err = errFlush
+	if _, err := w.Write(make([]byte, 100)); !errors.Is(err, errFlush) {
+		t.Errorf("Want %v, got %v.", errFlush, err)
+	}
+}
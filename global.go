@@ -0,0 +1,142 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// globalRebalanceInterval is the period in which a GlobalLimiter drops idle
+// streams and redistributes its bandwidth.
+const globalRebalanceInterval = 100 * time.Millisecond
+
+// GlobalLimiter distributes a total bandwidth evenly among all active Readers
+// and Writers created by it. A background goroutine periodically drops
+// streams that have been idle for longer than the idle timeout and hands
+// their share to the remaining active streams. An idle stream rejoins as
+// soon as it is used again.
+//
+// The GlobalLimiter only holds on to streams while they are active, so
+// abandoned streams are released for garbage collection after the idle
+// timeout.
+type GlobalLimiter struct {
+	bandwidth   int
+	idleTimeout time.Duration
+	streams     sync.Map // *limiter -> struct{}
+	mu          sync.Mutex
+	done        chan struct{}
+	closeOnce   sync.Once
+}
+
+// NewGlobalLimiter returns a new GlobalLimiter that distributes bandwidth
+// among its active streams. Streams without any Read or Write within
+// idleTimeout are considered idle. If bandwidth is zero or negative, the
+// streams will not limit. Call Close to stop the background goroutine.
+func NewGlobalLimiter(bandwidth int, idleTimeout time.Duration) *GlobalLimiter {
+	g := &GlobalLimiter{
+		bandwidth:   bandwidth,
+		idleTimeout: idleTimeout,
+		done:        make(chan struct{}),
+	}
+	go g.run()
+	return g
+}
+
+// NewReader returns a new reader that wraps reader r and takes part in the
+// distribution of the global bandwidth.
+func (g *GlobalLimiter) NewReader(r io.Reader, opts ...Option) *Reader {
+	reader := NewReader(r, g.bandwidth, opts...)
+	g.attach(reader.lim)
+	return reader
+}
+
+// NewWriter returns a new writer that wraps writer w and takes part in the
+// distribution of the global bandwidth.
+func (g *GlobalLimiter) NewWriter(w io.Writer, opts ...Option) *Writer {
+	writer := NewWriter(w, g.bandwidth, opts...)
+	g.attach(writer.lim)
+	return writer
+}
+
+// Close stops the background goroutine. Streams keep the bandwidth share
+// they had at that point.
+func (g *GlobalLimiter) Close() error {
+	g.closeOnce.Do(func() { close(g.done) })
+	return nil
+}
+
+func (g *GlobalLimiter) attach(l *limiter) {
+	l.global = g
+	atomic.StoreInt64(&l.lastUse, time.Now().UnixNano())
+	g.register(l)
+}
+
+// register adds l to the active streams and redistributes the bandwidth if l
+// has not been active before.
+func (g *GlobalLimiter) register(l *limiter) {
+	if _, loaded := g.streams.LoadOrStore(l, struct{}{}); !loaded {
+		g.rebalance()
+	}
+}
+
+func (g *GlobalLimiter) run() {
+	ticker := time.NewTicker(globalRebalanceInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			g.rebalance()
+		case <-g.done:
+			return
+		}
+	}
+}
+
+// rebalance drops idle streams and splits the bandwidth evenly among the
+// remaining ones.
+func (g *GlobalLimiter) rebalance() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	idleSince := time.Now().Add(-g.idleTimeout).UnixNano()
+	var active []*limiter
+	g.streams.Range(func(key, _ interface{}) bool {
+		l := key.(*limiter)
+		if atomic.LoadInt64(&l.lastUse) < idleSince {
+			g.streams.Delete(l)
+			return true
+		}
+		active = append(active, l)
+		return true
+	})
+
+	if len(active) == 0 {
+		return
+	}
+
+	share := float64(g.bandwidth)
+	if share > 0 {
+		share /= float64(len(active))
+	}
+	for _, l := range active {
+		l.setBandwidth(share)
+	}
+}
@@ -0,0 +1,60 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestGlobalLimiter(t *testing.T) {
+	t.Parallel()
+
+	g := NewGlobalLimiter(1000, 50*time.Millisecond)
+	defer g.Close()
+
+	r1 := g.NewReader(bytes.NewReader(make([]byte, 1000)))
+	r2 := g.NewReader(bytes.NewReader(make([]byte, 1000)))
+	assertBandwidth(t, "both active", r1, 500)
+	assertBandwidth(t, "both active", r2, 500)
+
+	// Keep r1 busy while r2 idles.
+	p := make([]byte, 1)
+	deadline := time.Now().Add(300 * time.Millisecond)
+	for time.Now().Before(deadline) {
+		if _, err := r1.Read(p); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assertBandwidth(t, "r2 idle", r1, 1000)
+
+	// r2 rejoins on its next read.
+	if _, err := r2.Read(p); err != nil {
+		t.Fatal(err)
+	}
+	assertBandwidth(t, "r2 rejoined", r1, 500)
+	assertBandwidth(t, "r2 rejoined", r2, 500)
+}
+
+func assertBandwidth(t *testing.T, name string, r *Reader, want float64) {
+	t.Helper()
+	if got := r.lim.getBandwidth(); got != want {
+		t.Errorf("%s: want bandwidth %f, got %f.", name, want, got)
+	}
+}
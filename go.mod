@@ -1,3 +1,5 @@
 module github.com/jwkohnen/bwio
 
 go 1.15
+
+require github.com/leanovate/gopter v0.2.11
@@ -0,0 +1,71 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package grpcmw provides gRPC middleware that limits the bandwidth of RPCs.
+package grpcmw
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryServerInterceptor returns a gRPC interceptor that limits unary RPCs
+// to the given bandwidth in bytes per second. It measures the serialized
+// sizes of the request and the response and, once the handler has returned,
+// sleeps for as long as transferring both at the given bandwidth would take,
+// less the time the handler already took. Messages that are not protocol
+// buffers count as zero bytes. If bandwidth is zero or negative, the
+// interceptor will not limit.
+//
+// If ctx is done while sleeping, the interceptor returns ctx.Err().
+func UnaryServerInterceptor(bandwidth int) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if bandwidth <= 0 {
+			return handler(ctx, req)
+		}
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		size := messageSize(req) + messageSize(resp)
+		penalty := time.Duration(size)*time.Second/time.Duration(bandwidth) - time.Since(start)
+		if penalty <= 0 {
+			return resp, err
+		}
+
+		timer := time.NewTimer(penalty)
+		defer timer.Stop()
+
+		select {
+		case <-timer.C:
+			return resp, err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// messageSize returns the serialized size of m, if it is a protocol buffer
+// message, or zero otherwise.
+func messageSize(m interface{}) int {
+	if pm, ok := m.(proto.Message); ok {
+		return proto.Size(pm)
+	}
+	return 0
+}
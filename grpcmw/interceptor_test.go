@@ -0,0 +1,79 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package grpcmw
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func echo(_ context.Context, req interface{}) (interface{}, error) {
+	return req, nil
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	t.Parallel()
+
+	req := wrapperspb.Bytes(make([]byte, 1000))
+	interceptor := UnaryServerInterceptor(10000)
+
+	start := time.Now()
+	resp, err := interceptor(context.Background(), req, new(grpc.UnaryServerInfo), echo)
+	dur := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp != req {
+		t.Errorf("Want response %v, got %v.", req, resp)
+	}
+	// Request and response are about 1000 bytes each.
+	if dur < 180*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestUnaryServerInterceptorUnlimited(t *testing.T) {
+	t.Parallel()
+
+	req := wrapperspb.Bytes(make([]byte, 1000))
+	for _, bw := range []int{-1, 0} {
+		start := time.Now()
+		if _, err := UnaryServerInterceptor(bw)(context.Background(), req, new(grpc.UnaryServerInfo), echo); err != nil {
+			t.Fatal(err)
+		}
+		if dur := time.Since(start); dur > 50*time.Millisecond {
+			t.Errorf("Took %s, want no delay.", dur)
+		}
+	}
+}
+
+func TestUnaryServerInterceptorCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req := wrapperspb.Bytes(make([]byte, 1000))
+	_, err := UnaryServerInterceptor(100)(ctx, req, new(grpc.UnaryServerInfo), echo)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+// guardTolerance is the factor by which GuardReader tolerates the achieved
+// rate to exceed the bandwidth.
+const guardTolerance = 1.05
+
+// guardLogf logs the violations of GuardReader; tests replace it.
+var guardLogf = log.Printf
+
+// GuardReader wraps another reader, maintains a given bandwidth like Reader
+// and checks after each read that the achieved rate does not exceed the
+// bandwidth by more than 5%. A violation is logged, or panics in strict mode,
+// see WithStrictMode. GuardReader is a debugging and testing tool to catch
+// cases in which the limiter fails to enforce the bandwidth.
+type GuardReader struct {
+	r *Reader
+}
+
+// NewGuardReader returns a new GuardReader that wraps r and maintains the
+// given bandwidth. If bandwidth is zero or negative, the GuardReader neither
+// limits nor checks.
+func NewGuardReader(r io.Reader, bandwidth int, opts ...Option) *GuardReader {
+	return &GuardReader{r: NewReader(r, bandwidth, opts...)}
+}
+
+// WithStrictMode makes a GuardReader panic instead of logging, if the
+// achieved rate exceeds the bandwidth. Other types ignore it.
+func WithStrictMode() Option {
+	return func(l *limiter) { l.strict = true }
+}
+
+// Read implements the io.Reader interface, maintains the given bandwidth and
+// checks the achieved rate afterwards.
+func (g *GuardReader) Read(p []byte) (int, error) {
+	n, err := g.r.Read(p)
+	g.check()
+	return n, err
+}
+
+// Stats returns the stats of the GuardReader.
+func (g *GuardReader) Stats() Stats {
+	return g.r.Stats()
+}
+
+func (g *GuardReader) check() {
+	bandwidth := g.r.lim.getBandwidth()
+	if bandwidth <= 0 {
+		return
+	}
+	rate := g.r.Stats().AchievedBandwidth
+	if rate <= bandwidth*guardTolerance {
+		return
+	}
+
+	msg := fmt.Sprintf("bwio: achieved rate of %.0f B/s exceeds bandwidth of %.0f B/s", rate, bandwidth)
+	if g.r.lim.strict {
+		panic(msg)
+	}
+	guardLogf("%s", msg)
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+)
+
+// TestGuardReader does not run in parallel, because it replaces guardLogf.
+func TestGuardReader(t *testing.T) {
+	testt := []struct {
+		name      string
+		opts      []Option
+		wantPanic bool
+		wantLog   bool
+	}{
+		{"within", []Option{WithStrictMode()}, false, false},
+		// The initial credit lets the first reads pass unthrottled.
+		{"exceeding", []Option{WithInitialCredit(1000)}, false, true},
+		{"strict", []Option{WithInitialCredit(1000), WithStrictMode()}, true, false},
+	}
+
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			var logged []string
+			guardLogf = func(format string, v ...interface{}) {
+				logged = append(logged, format)
+			}
+			defer func() { guardLogf = log.Printf }()
+
+			g := NewGuardReader(bytes.NewReader(make([]byte, 300)), 1000, testc.opts...)
+
+			var panicked interface{}
+			func() {
+				defer func() { panicked = recover() }()
+				_, _ = io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{g}, make([]byte, 100))
+			}()
+
+			if testc.wantPanic {
+				if msg, ok := panicked.(string); !ok || !strings.Contains(msg, "exceeds bandwidth") {
+					t.Errorf("Want panic about the exceeded bandwidth, got %v.", panicked)
+				}
+			} else if panicked != nil {
+				t.Errorf("Want no panic, got %v.", panicked)
+			}
+			if got := len(logged) > 0; got != testc.wantLog {
+				t.Errorf("Want logged %t, got %t.", testc.wantLog, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Limiter exposes the rate calculation of Reader and Writer without the
+// sleeping. It allows callers to implement their own sleep strategy, e.g.
+// with time.AfterFunc, a channel or a select, while reusing the limiting
+// logic of this package including all options. A Limiter is not safe for
+// concurrent use, except for SetBandwidth.
+type Limiter struct {
+	lim *limiter
+}
+
+// NewLimiter returns a new Limiter that maintains the given bandwidth. It
+// starts measuring time immediately. If bandwidth is zero or negative, the
+// Limiter will not limit.
+func NewLimiter(bandwidth int, opts ...Option) *Limiter {
+	l := newLimiter(float64(bandwidth), opts)
+	l.init()
+	return &Limiter{lim: l}
+}
+
+// ComputePenalty accounts for n bytes transferred with a buffer of bufSize
+// bytes and returns how long the caller should pause before the next
+// transfer in order to maintain the bandwidth. ComputePenalty does not sleep,
+// but assumes that the caller pauses for the returned duration. If the caller
+// does not, the penalties of subsequent calls grow accordingly.
+func (lim *Limiter) ComputePenalty(n, bufSize int) time.Duration {
+	return lim.lim.computePenalty(n, bufSize)
+}
+
+// SetBandwidth changes the bandwidth of the Limiter. If bandwidth is zero or
+// negative, the Limiter will not limit.
+func (lim *Limiter) SetBandwidth(bandwidth int) {
+	lim.lim.setBandwidth(float64(bandwidth))
+}
+
+// Limit implements Pacer. It returns the penalty of ComputePenalty and
+// whether a stall discarded the accumulated credits.
+func (lim *Limiter) Limit(n, bufSize int) (time.Duration, bool) {
+	stalls := atomic.LoadInt64(&lim.lim.stalls)
+	penalty := lim.ComputePenalty(n, bufSize)
+	return penalty, atomic.LoadInt64(&lim.lim.stalls) != stalls
+}
@@ -0,0 +1,53 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterComputePenalty(t *testing.T) {
+	t.Parallel()
+
+	lim := NewLimiter(1000)
+
+	start := time.Now()
+	penalty := lim.ComputePenalty(100, 100)
+	if dur := time.Since(start); dur > 20*time.Millisecond {
+		t.Errorf("Took %s, want no sleep.", dur)
+	}
+	if penalty < 90*time.Millisecond || penalty > 100*time.Millisecond {
+		t.Errorf("Want penalty of 100ms, got %s.", penalty)
+	}
+
+	// Not pausing adds up the penalties.
+	penalty = lim.ComputePenalty(100, 100)
+	if penalty < 190*time.Millisecond || penalty > 200*time.Millisecond {
+		t.Errorf("Want penalty of 200ms, got %s.", penalty)
+	}
+}
+
+func TestLimiterUnlimited(t *testing.T) {
+	t.Parallel()
+
+	lim := NewLimiter(1000)
+	lim.SetBandwidth(0)
+	if penalty := lim.ComputePenalty(1<<20, 1<<20); penalty != 0 {
+		t.Errorf("Want no penalty, got %s.", penalty)
+	}
+}
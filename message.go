@@ -0,0 +1,88 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MessageLimiter limits the number of messages per second rather than the
+// number of bytes. It complements the byte oriented Reader and Writer for
+// message oriented protocols like MQTT or AMQP. A MessageLimiter is safe for
+// concurrent use.
+type MessageLimiter struct {
+	interval time.Duration
+
+	mu   sync.Mutex // guards next
+	next time.Time
+}
+
+// NewMessageLimiter returns a new MessageLimiter that lets through
+// messagesPerSecond messages per second. If messagesPerSecond is zero or
+// negative, the MessageLimiter will not limit.
+func NewMessageLimiter(messagesPerSecond int) *MessageLimiter {
+	ml := new(MessageLimiter)
+	if messagesPerSecond > 0 {
+		ml.interval = time.Second / time.Duration(messagesPerSecond)
+	}
+	return ml
+}
+
+// Acquire blocks until the next message may pass. If ctx is done before
+// that, Acquire returns ctx.Err(). It gives up the reserved slot only if no
+// later caller has reserved one in the meantime.
+func (ml *MessageLimiter) Acquire(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if ml.interval <= 0 {
+		return nil
+	}
+
+	ml.mu.Lock()
+	now := time.Now()
+	if ml.next.Before(now) {
+		ml.next = now
+	}
+	reserved := ml.next
+	wait := reserved.Sub(now)
+	ml.next = reserved.Add(ml.interval)
+	ml.mu.Unlock()
+
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		ml.mu.Lock()
+		// Handing the slot back to later callers would let two of them
+		// pass at the same time.
+		if reserved.Add(ml.interval).Equal(ml.next) {
+			ml.next = reserved
+		}
+		ml.mu.Unlock()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,125 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestMessageLimiter(t *testing.T) {
+	t.Parallel()
+
+	ml := NewMessageLimiter(100)
+	ctx := context.Background()
+
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		if err := ml.Acquire(ctx); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dur := time.Since(start)
+	if dur < 90*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
+
+func TestMessageLimiterUnlimited(t *testing.T) {
+	t.Parallel()
+
+	for _, mps := range []int{-1, 0} {
+		ml := NewMessageLimiter(mps)
+		for i := 0; i < 1000; i++ {
+			if err := ml.Acquire(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestMessageLimiterCancel(t *testing.T) {
+	t.Parallel()
+
+	ml := NewMessageLimiter(1)
+	if err := ml.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := ml.Acquire(ctx)
+	dur := time.Since(start)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+	if dur > 500*time.Millisecond {
+		t.Errorf("Took %s, want 20ms.", dur)
+	}
+}
+
+func TestMessageLimiterCancelWithWaiters(t *testing.T) {
+	t.Parallel()
+
+	ml := NewMessageLimiter(10)
+	if err := ml.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first waiter reserves the slot at 100ms and cancels, while two
+	// more wait for the slots at 200ms and 300ms.
+	ctx, cancel := context.WithCancel(context.Background())
+	errc := make(chan error, 1)
+	go func() { errc <- ml.Acquire(ctx) }()
+	time.Sleep(5 * time.Millisecond)
+
+	granted := make(chan time.Time, 3)
+	acquire := func() {
+		if err := ml.Acquire(context.Background()); err != nil {
+			t.Error(err)
+		}
+		granted <- time.Now()
+	}
+	for i := 0; i < 2; i++ {
+		go acquire()
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-errc; err != context.Canceled {
+		t.Errorf("Want %v, got %v.", context.Canceled, err)
+	}
+
+	// The slot given up is still behind the other waiters, so a new one must
+	// not get the slot of the last waiter.
+	go acquire()
+
+	var times []time.Time
+	for i := 0; i < 3; i++ {
+		times = append(times, <-granted)
+	}
+	sort.Slice(times, func(i, j int) bool { return times[i].Before(times[j]) })
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 80*time.Millisecond {
+			t.Errorf("Want messages 100ms apart, got %s.", gap)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+// Clock IDs from <linux/time.h>, which the syscall package does not export.
+const (
+	clockProcessCPUTimeID = 2
+	clockMonotonicRaw     = 4
+)
+
+// WithMonotonicRaw makes the limiter measure time with CLOCK_MONOTONIC_RAW
+// instead of time.Now. Unlike CLOCK_MONOTONIC, the raw clock is not subject
+// to frequency adjustments by NTP, which makes it more stable for rate
+// calculations. The option is only effective on Linux and has no effect if
+// the clock is not available.
+func WithMonotonicRaw() Option {
+	return func(l *limiter) {
+		if _, ok := clockGettime(clockMonotonicRaw); ok {
+			l.now = nowMonotonicRaw
+		}
+	}
+}
+
+// nowMonotonicRaw returns the time of CLOCK_MONOTONIC_RAW. Only differences
+// between the returned times are meaningful.
+func nowMonotonicRaw() time.Time {
+	t, _ := clockGettime(clockMonotonicRaw)
+	return t
+}
+
+// clockGettime returns the time of the given clock and whether the clock is
+// available.
+func clockGettime(clock uintptr) (time.Time, bool) {
+	var ts syscall.Timespec
+	_, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clock, uintptr(unsafe.Pointer(&ts)), 0)
+	if errno != 0 {
+		return time.Time{}, false
+	}
+	return time.Unix(ts.Unix()), true
+}
@@ -0,0 +1,36 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonotonicRaw(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1000, []Option{WithMonotonicRaw()})
+
+	t1 := l.now()
+	time.Sleep(10 * time.Millisecond)
+	t2 := l.now()
+
+	if d := t2.Sub(t1); d < 9*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("Took %s, want 10ms.", d)
+	}
+}
@@ -0,0 +1,28 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// NewMultiWriter returns a new Writer that duplicates its writes to all the
+// given writers like io.MultiWriter and maintains the given bandwidth for
+// the bytes written once, not per destination. If any of the writers fails,
+// the write stops and returns that error. If bandwidth is zero or negative,
+// the Writer will not limit.
+func NewMultiWriter(bandwidth int, writers ...io.Writer) *Writer {
+	return NewWriter(io.MultiWriter(writers...), bandwidth)
+}
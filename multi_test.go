@@ -0,0 +1,55 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMultiWriter(t *testing.T) {
+	t.Parallel()
+
+	var a, b bytes.Buffer
+	w := NewMultiWriter(1000, &a, &b)
+
+	// 200 bytes at 1000 B/s take 200ms, regardless of the two destinations.
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := w.Write(bytes.Repeat([]byte{byte('a' + i)}, 100)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dur := time.Since(start); dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+	if a.Len() != 200 || a.String() != b.String() {
+		t.Errorf("Want 200 identical bytes, got %q and %q.", a.String(), b.String())
+	}
+}
+
+func TestMultiWriterError(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w := NewMultiWriter(0, &buf, new(pWriter))
+	if _, err := w.Write([]byte("x")); !errors.Is(err, errPoison) {
+		t.Errorf("Want %v, got %v.", errPoison, err)
+	}
+}
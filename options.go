@@ -0,0 +1,266 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"time"
+)
+
+// Reasons passed to the callback configured with WithOnReset.
+const (
+	// ResetPenaltyPaid means the limiter slept off a penalty and started
+	// a new bucket.
+	ResetPenaltyPaid = "penalty_paid"
+	// ResetStallDetected means the stream stalled for longer than the
+	// stall threshold and the accumulated credits were discarded.
+	ResetStallDetected = "stall_detected"
+	// ResetPacer means the Pacer set by WithPacer discarded its state.
+	ResetPacer = "pacer"
+)
+
+// Option configures the limiter of a Reader or Writer.
+type Option func(*limiter)
+
+// WithStallThreshold overrides the base duration after which the limiter
+// considers the stream stalled and resets its bucket. The default is one
+// second. The compensation for high buffer size / bandwidth ratios is still
+// added on top. A zero or negative duration keeps the default.
+func WithStallThreshold(d time.Duration) Option {
+	return func(l *limiter) {
+		if d > 0 {
+			l.stallThreshold = d
+		}
+	}
+}
+
+// WithOnReset registers fn to be called whenever the limiter resets its
+// bucket. The reason is one of ResetPenaltyPaid, ResetStallDetected and
+// ResetPacer. fn is called synchronously from within Read or Write, so it
+// should return quickly.
+func WithOnReset(fn func(reason string)) Option {
+	return func(l *limiter) { l.onReset = fn }
+}
+
+// WithSlack lets the limiter tolerate rates slightly above the bandwidth.
+// Throttling only kicks in once the measured rate exceeds bandwidth*(1+pct),
+// e.g. a pct of 0.1 allows for 10 % overshoot. This avoids constant short
+// sleeps when the source delivers just above the limit due to timing noise.
+// A zero or negative pct disables the slack.
+func WithSlack(pct float64) Option {
+	return func(l *limiter) {
+		if pct > 0 {
+			l.slack = pct
+		}
+	}
+}
+
+// WithLeakyBucket switches the limiter from the time bucket to a leaky
+// bucket. The leaky bucket drains at a constant rate regardless of input
+// timing and never accumulates credits, so there are no bursts after a reset
+// or stall. This suits streaming use cases that need a strictly constant
+// output rate. Stall detection, slack and reset notifications do not apply.
+func WithLeakyBucket() Option {
+	return func(l *limiter) { l.leaky = true }
+}
+
+// WithOperationLogger registers fn to be called after each Read or Write with
+// the operation ("read" or "write"), the number of bytes transferred, the
+// penalty the limiter slept for and the current time. This yields a
+// timestamped trace of every operation, e.g. to plot rate curves of real
+// transfers. fn is called synchronously, so it should return quickly.
+func WithOperationLogger(fn func(op string, n int, penalty time.Duration, ts time.Time)) Option {
+	return func(l *limiter) { l.opLogger = fn }
+}
+
+// WithSlidingWindow switches the limiter to a sliding window strategy. It
+// keeps a record of all transfers within the last window and sleeps
+// whenever the bytes within the window would exceed bandwidth*window. Unlike
+// the time bucket, the rate is enforced over any period of the given length,
+// e.g. "no more than 10 MB in any 60 seconds", at the cost of memory
+// proportional to the number of operations within the window. Stall
+// detection, slack and reset notifications do not apply. A zero or negative
+// window disables the sliding window.
+func WithSlidingWindow(window time.Duration) Option {
+	return func(l *limiter) {
+		if window > 0 {
+			l.window = window
+		}
+	}
+}
+
+// WithMinSleep skips penalties shorter than d and carries the deficit over to
+// the next operation instead, until the accumulated penalty is worth a sleep.
+// This avoids sleeping for durations below the granularity of the scheduler,
+// which usually oversleeps them considerably and thus reduces the throughput
+// at high bandwidths and small buffers. The default is zero, i.e. every
+// penalty is slept.
+func WithMinSleep(d time.Duration) Option {
+	return func(l *limiter) { l.minSleep = d }
+}
+
+// WithMinInterval makes sure that operations start no more frequently than
+// once per d. If a Read or Write follows its predecessor sooner than that, it
+// sleeps for the remaining interval first. This is useful for polling
+// protocols, where reading faster than the source produces data wastes CPU.
+// The interval is enforced in addition to the bandwidth.
+func WithMinInterval(d time.Duration) Option {
+	return func(l *limiter) { l.minInterval = d }
+}
+
+// WithBackpressureClose makes Read and Write fail with
+// ErrBackpressureLimitExceeded instead of sleeping, if the penalty of a
+// single operation exceeds threshold. This suits sources that close the
+// connection if the client reads too slowly: the caller can reconnect with a
+// higher bandwidth instead. The bytes transferred by the failing operation
+// are still returned. A zero or negative threshold disables the check.
+func WithBackpressureClose(threshold time.Duration) Option {
+	return func(l *limiter) { l.backpressure = threshold }
+}
+
+// WithContext attaches ctx to the limiter. Once ctx is done, Read and Write
+// return ctx.Err(), even in the middle of a penalty sleep.
+func WithContext(ctx context.Context) Option {
+	return func(l *limiter) { l.ctx = ctx }
+}
+
+// WithSoftLimit allows for short bursts above the bandwidth. The limiter lets
+// rates up to bandwidth*burstFactor through for at most burstDuration, then
+// throttles such that the long-term average matches the bandwidth again.
+// Rates beyond bandwidth*burstFactor are throttled right away. The rate is
+// measured since the start of the current bucket. A burstFactor of one or
+// less disables the soft limit.
+func WithSoftLimit(burstFactor float64, burstDuration time.Duration) Option {
+	return func(l *limiter) {
+		l.burstFactor = burstFactor
+		l.burstDuration = burstDuration
+	}
+}
+
+// WithExponentialBackoff smooths out the sawtooth pattern of bursty sources,
+// e.g. on high-latency links that deliver data in large chunks. Each penalty
+// that immediately follows another one is doubled, up to eight times the
+// computed penalty; the first operation without penalty resets the backoff.
+// The smoother throttling comes at the cost of a lower average throughput.
+func WithExponentialBackoff() Option {
+	return func(l *limiter) { l.expBackoff = true }
+}
+
+// WithJitter randomizes each penalty by multiplying it with a random value in
+// [1-fraction, 1+fraction]. This keeps many connections limited to the same
+// bandwidth from waking up in lockstep, while the long-term average rate is
+// preserved. The fraction is capped at one; zero or less disables jitter.
+func WithJitter(fraction float64) Option {
+	return func(l *limiter) {
+		if fraction > 1 {
+			fraction = 1
+		}
+		l.jitter = fraction
+	}
+}
+
+// WithBucketCeiling caps the credit the limiter accumulates while the source
+// is idle at maxBytes. When data resumes, the first maxBytes flow freely
+// before throttling kicks in, like the burst size of a token bucket. Without
+// a ceiling, the credit is only discarded by the stall detection. A zero or
+// negative maxBytes disables the ceiling.
+func WithBucketCeiling(maxBytes int64) Option {
+	return func(l *limiter) { l.bucketCeiling = maxBytes }
+}
+
+// WithInitialCredit grants bytes of free credit before throttling starts,
+// e.g. to send the headers of a response immediately and throttle the body.
+// The credit is granted when the limiter starts measuring time, including
+// after Reset. Combined with WithBucketCeiling, which also caps the initial
+// credit, this yields the semantics of a token bucket that starts full. A zero
+// or negative value grants no credit.
+func WithInitialCredit(bytes int64) Option {
+	return func(l *limiter) {
+		if bytes > 0 {
+			l.initialCredit = bytes
+		}
+	}
+}
+
+// WithInitialDebt starts the limiter in debt: the first Read or Write sleeps
+// for debt before it transfers any data, and so does the first one after
+// Reset. Streams started with increasing debts thus stagger their first
+// operations and ramp up gradually. A zero or negative debt has no effect.
+func WithInitialDebt(debt time.Duration) Option {
+	return func(l *limiter) {
+		if debt > 0 {
+			l.initialDebt = debt
+		}
+	}
+}
+
+// WithTotalSize announces the total number of bytes the Reader or Writer is
+// going to transfer, e.g. from a Content-Length header. Options like
+// WithDeadlineProportional need it.
+func WithTotalSize(size int64) Option {
+	return func(l *limiter) { l.totalSize = size }
+}
+
+// WithLatency simulates the setup of a connection with the round-trip time
+// rtt: the first Read or Write sleeps for rtt/2 before it starts, and so does
+// the first one after Reset. The limiter starts measuring time afterwards.
+// A zero or negative rtt disables the latency.
+func WithLatency(rtt time.Duration) Option {
+	return func(l *limiter) { l.latency = rtt }
+}
+
+// WithPerPacketLatency simulates the round-trip time rtt of each packet:
+// every Read or Write sleeps for rtt/2 before it starts, in addition to the
+// penalties that maintain the bandwidth. Together with the bandwidth and
+// WithLatency, this turns a Reader or Writer into a basic network emulator
+// for tests. A zero or negative rtt disables the latency.
+func WithPerPacketLatency(rtt time.Duration) Option {
+	return func(l *limiter) { l.packetLatency = rtt }
+}
+
+// WithSyscallCost subtracts d from each penalty before sleeping, accounting
+// for the time the system call of the operation took itself. At very high
+// bandwidths with small buffers, this overhead is a significant part of each
+// penalty. Something like 2µs is reasonable for network reads on Linux. The
+// default is zero.
+func WithSyscallCost(d time.Duration) Option {
+	return func(l *limiter) { l.syscallCost = d }
+}
+
+// WithIsochronousMode enforces a fixed cadence instead of an average rate, as
+// required by isochronous I/O like audio streaming, e.g. 1920 bytes every
+// 40ms for 48 kHz stereo PCM. Each period provides bytesPerPeriod bytes; once
+// they are used up, the operation blocks until the next period starts. Bytes
+// left unused at the end of a period do not carry over. The mode replaces the
+// bandwidth, which is ignored. Zero or negative values disable the mode.
+func WithIsochronousMode(bytesPerPeriod int, period time.Duration) Option {
+	return func(l *limiter) {
+		if bytesPerPeriod > 0 && period > 0 {
+			l.isoBytes = int64(bytesPerPeriod)
+			l.isoPeriod = period
+		}
+	}
+}
+
+// WithTicker makes the limiter wait for the ticks of a time.Ticker instead of
+// sleeping for each penalty on its own timer. At high bandwidths with small
+// buffers, this trades many tiny sleeps for fewer wake-ups on a fixed 5ms
+// grid and produces more uniform pacing. Penalties are rounded up to the
+// next tick, the excess is credited to subsequent operations.
+func WithTicker() Option {
+	return func(l *limiter) { l.sleeper = newTickerSleeper(defaultTickInterval) }
+}
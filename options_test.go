@@ -0,0 +1,546 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStallThreshold(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name      string
+		opts      []Option
+		wantReset bool
+	}{
+		{"default", nil, false},
+		{"short", []Option{WithStallThreshold(50 * time.Millisecond)}, true},
+		{"zero", []Option{WithStallThreshold(0)}, false},
+	}
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newLimiter(1<<20, testc.opts)
+			l.init()
+			time.Sleep(100 * time.Millisecond)
+			l.limit(1, 1)
+			if gotReset := l.bucket == 0; gotReset != testc.wantReset {
+				t.Errorf("Want reset %t, got bucket %d.", testc.wantReset, l.bucket)
+			}
+		})
+	}
+}
+
+func TestOnReset(t *testing.T) {
+	t.Parallel()
+
+	var reasons []string
+	l := newLimiter(1<<20, []Option{
+		WithStallThreshold(20 * time.Millisecond),
+		WithOnReset(func(reason string) { reasons = append(reasons, reason) }),
+	})
+	l.init()
+
+	// Exceed the bandwidth to provoke a penalty.
+	l.limit(1<<16, 1<<16)
+	// Stall the stream.
+	time.Sleep(50 * time.Millisecond)
+	l.limit(1, 1)
+
+	want := []string{ResetPenaltyPaid, ResetStallDetected}
+	if !reflect.DeepEqual(reasons, want) {
+		t.Errorf("Want reasons %v, got %v.", want, reasons)
+	}
+}
+
+func TestSlack(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1000, []Option{WithSlack(0.5)})
+	l.init()
+	time.Sleep(100 * time.Millisecond)
+
+	// 120 bytes in 100ms is 1200 B/s, within the slack.
+	l.limit(120, 120)
+	if l.bucket != 120 {
+		t.Errorf("Want no throttling within slack, got bucket %d.", l.bucket)
+	}
+
+	// 220 bytes in 100ms is 2200 B/s, beyond the slack.
+	l.limit(100, 100)
+	if l.bucket != 0 {
+		t.Errorf("Want throttling beyond slack, got bucket %d.", l.bucket)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(10000, []Option{WithLeakyBucket()})
+	l.init()
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		// 100 bytes drain in 10ms each.
+		l.limit(100, 100)
+	}
+	dur := time.Since(start)
+	if dur < 40*time.Millisecond || dur > 200*time.Millisecond {
+		t.Errorf("Took %s, want 40ms.", dur)
+	}
+}
+
+func TestOperationLogger(t *testing.T) {
+	t.Parallel()
+
+	type operation struct {
+		op string
+		n  int
+	}
+	var (
+		ops     []operation
+		penalty time.Duration
+	)
+	logger := WithOperationLogger(func(op string, n int, p time.Duration, _ time.Time) {
+		ops = append(ops, operation{op, n})
+		penalty += p
+	})
+
+	r := NewReader(bytes.NewReader(make([]byte, 100)), 1000, logger)
+	w := NewWriter(ioutil.Discard, 1000, logger)
+	// Hide Reader.WriteTo, so that io.CopyBuffer uses the 60 byte buffer.
+	if _, err := io.CopyBuffer(w, struct{ io.Reader }{r}, make([]byte, 60)); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []operation{
+		{"read", 60},
+		{"write", 60},
+		{"read", 40},
+		{"write", 40},
+		{"read", 0},
+	}
+	if !reflect.DeepEqual(ops, want) {
+		t.Errorf("Want operations %v, got %v.", want, ops)
+	}
+	if penalty <= 0 {
+		t.Errorf("Want penalties to be logged, got %s.", penalty)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	t.Parallel()
+
+	// 100 bytes per 100ms window.
+	l := newLimiter(1000, []Option{WithSlidingWindow(100 * time.Millisecond)})
+	l.init()
+
+	if penalty, _ := l.limit(60, 60); penalty != 0 {
+		t.Errorf("Want no penalty within the window, got %s.", penalty)
+	}
+	if penalty, _ := l.limit(60, 60); penalty < 50*time.Millisecond {
+		t.Errorf("Want to wait for the first transfer to leave the window, got %s.", penalty)
+	}
+	if penalty, _ := l.limit(30, 30); penalty != 0 {
+		t.Errorf("Want no penalty within the window, got %s.", penalty)
+	}
+}
+
+func TestMinSleep(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1000, []Option{WithMinSleep(50 * time.Millisecond)})
+	l.init()
+
+	// 10ms and 20ms of penalty are deferred.
+	for i := 0; i < 2; i++ {
+		if penalty, _ := l.limit(10, 10); penalty != 0 {
+			t.Errorf("Want penalty to be deferred, got %s.", penalty)
+		}
+	}
+
+	// 60ms of penalty are worth a sleep.
+	if penalty, _ := l.limit(40, 40); penalty < 50*time.Millisecond {
+		t.Errorf("Want accumulated penalty, got %s.", penalty)
+	}
+	if l.bucket != 0 {
+		t.Errorf("Want bucket to be reset after the sleep, got %d.", l.bucket)
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 4)), 0, WithMinInterval(20*time.Millisecond))
+	p := make([]byte, 1)
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		if _, err := r.Read(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dur := time.Since(start)
+	if dur < 60*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 60ms.", dur)
+	}
+}
+
+func TestBackpressureClose(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 1000, WithBackpressureClose(50*time.Millisecond))
+
+	// 10 bytes are a penalty of 10ms.
+	n, err := r.Read(make([]byte, 10))
+	if err != nil {
+		t.Errorf("Want no error below the threshold, got %v.", err)
+	}
+	if n != 10 {
+		t.Errorf("Want 10 bytes, got %d.", n)
+	}
+
+	// 100 bytes are a penalty of 100ms.
+	start := time.Now()
+	n, err = r.Read(make([]byte, 100))
+	dur := time.Since(start)
+	if err != ErrBackpressureLimitExceeded {
+		t.Errorf("Want %v, got %v.", ErrBackpressureLimitExceeded, err)
+	}
+	if n != 100 {
+		t.Errorf("Want 100 bytes, got %d.", n)
+	}
+	if dur > 40*time.Millisecond {
+		t.Errorf("Took %s, want no sleep.", dur)
+	}
+}
+
+func TestSoftLimit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("burst", func(t *testing.T) {
+		t.Parallel()
+
+		l := newLimiter(1000, []Option{WithSoftLimit(2, 100*time.Millisecond)})
+		l.init()
+
+		// 75 bytes in 50ms are 1500 B/s.
+		time.Sleep(50 * time.Millisecond)
+		if penalty := l.computePenalty(75, 75); penalty != 0 {
+			t.Errorf("Want burst to pass, got penalty %s.", penalty)
+		}
+
+		// 115 bytes in 80ms are about 1440 B/s.
+		time.Sleep(30 * time.Millisecond)
+		if penalty := l.computePenalty(40, 40); penalty != 0 {
+			t.Errorf("Want burst to pass, got penalty %s.", penalty)
+		}
+
+		// 315 bytes in 180ms are 1750 B/s, but the burst lasted too long.
+		time.Sleep(100 * time.Millisecond)
+		if penalty := l.computePenalty(200, 200); penalty <= 0 {
+			t.Error("Want sustained burst to be throttled.")
+		}
+	})
+
+	t.Run("excess", func(t *testing.T) {
+		t.Parallel()
+
+		l := newLimiter(1000, []Option{WithSoftLimit(2, 100*time.Millisecond)})
+		l.init()
+
+		// 150 bytes in 50ms are 3000 B/s.
+		time.Sleep(50 * time.Millisecond)
+		if penalty := l.computePenalty(150, 150); penalty <= 0 {
+			t.Error("Want excessive rate to be throttled.")
+		}
+	})
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1000, []Option{WithExponentialBackoff()})
+
+	testt := []struct {
+		penalty time.Duration
+		want    time.Duration
+	}{
+		{10 * time.Millisecond, 10 * time.Millisecond},
+		{10 * time.Millisecond, 20 * time.Millisecond},
+		{10 * time.Millisecond, 40 * time.Millisecond},
+		{10 * time.Millisecond, 80 * time.Millisecond},
+		{10 * time.Millisecond, 80 * time.Millisecond},
+		{0, 0},
+		{10 * time.Millisecond, 10 * time.Millisecond},
+	}
+
+	for i, testc := range testt {
+		if got := l.backoff(testc.penalty); got != testc.want {
+			t.Errorf("Operation %d: want penalty %s, got %s.", i, testc.want, got)
+		}
+	}
+
+	if got := newLimiter(1000, nil).backoff(time.Second); got != time.Second {
+		t.Errorf("Want penalty 1s without backoff, got %s.", got)
+	}
+}
+
+func TestJitter(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(1000, []Option{WithJitter(0.1)})
+
+	const penalty = 100 * time.Millisecond
+	seen := make(map[time.Duration]bool)
+	for i := 0; i < 100; i++ {
+		got := l.jitterPenalty(penalty)
+		if got < 90*time.Millisecond || got > 110*time.Millisecond {
+			t.Errorf("Want penalty within 90ms..110ms, got %s.", got)
+		}
+		seen[got] = true
+	}
+	if len(seen) < 2 {
+		t.Error("Want randomized penalties, got always the same.")
+	}
+
+	if got := l.jitterPenalty(0); got != 0 {
+		t.Errorf("Want no penalty, got %s.", got)
+	}
+}
+
+func TestBucketCeiling(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name    string
+		opts    []Option
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		// 200ms idle at 1000 B/s are 200 bytes of credit, enough for 150.
+		{"without", nil, 0, 0},
+		// The credit is capped at 100 bytes, so 50 bytes take 50ms.
+		{"with", []Option{WithBucketCeiling(100)}, 30 * time.Millisecond, 60 * time.Millisecond},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newLimiter(1000, testc.opts)
+			l.init()
+			time.Sleep(200 * time.Millisecond)
+
+			penalty, _ := l.limit(150, 150)
+			if penalty < testc.wantMin || penalty > testc.wantMax {
+				t.Errorf("Want penalty within %s..%s, got %s.", testc.wantMin, testc.wantMax, penalty)
+			}
+		})
+	}
+}
+
+func TestInitialCredit(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name    string
+		opts    []Option
+		n       int
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"without", nil, 400, 380 * time.Millisecond, 410 * time.Millisecond},
+		{"within", []Option{WithInitialCredit(500)}, 400, 0, 0},
+		// 100 bytes beyond the credit take 100ms at 1000 B/s.
+		{"beyond", []Option{WithInitialCredit(500)}, 600, 80 * time.Millisecond, 110 * time.Millisecond},
+		// The ceiling caps the credit at 200 bytes.
+		{"ceiling", []Option{WithInitialCredit(500), WithBucketCeiling(200)}, 400, 180 * time.Millisecond, 210 * time.Millisecond},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			l := newLimiter(1000, testc.opts)
+			l.init()
+
+			penalty, _ := l.limit(testc.n, testc.n)
+			if penalty < testc.wantMin || penalty > testc.wantMax {
+				t.Errorf("Want penalty within %s..%s, got %s.", testc.wantMin, testc.wantMax, penalty)
+			}
+		})
+	}
+}
+
+func TestLatency(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name    string
+		opts    []Option
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		{"none", nil, 0, 20 * time.Millisecond},
+		// Only the first of five reads sleeps for 50ms.
+		{"setup", []Option{WithLatency(100 * time.Millisecond)}, 50 * time.Millisecond, 80 * time.Millisecond},
+		// Each of five reads sleeps for 20ms.
+		{"per packet", []Option{WithPerPacketLatency(40 * time.Millisecond)}, 100 * time.Millisecond, 150 * time.Millisecond},
+		{"both", []Option{WithLatency(100 * time.Millisecond), WithPerPacketLatency(40 * time.Millisecond)}, 150 * time.Millisecond, 220 * time.Millisecond},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := NewReader(bytes.NewReader(make([]byte, 500)), 0, testc.opts...)
+			p := make([]byte, 100)
+
+			start := time.Now()
+			for i := 0; i < 5; i++ {
+				if _, err := r.Read(p); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if took := time.Since(start); took < testc.wantMin || took > testc.wantMax {
+				t.Errorf("Took %s, want %s..%s.", took, testc.wantMin, testc.wantMax)
+			}
+		})
+	}
+}
+
+func TestSyscallCost(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name    string
+		cost    time.Duration
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		// 100 bytes at 1000 B/s are a penalty of 100ms.
+		{"none", 0, 95 * time.Millisecond, 100 * time.Millisecond},
+		{"10ms", 10 * time.Millisecond, 85 * time.Millisecond, 90 * time.Millisecond},
+		{"exceeding", time.Second, 0, 0},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			var slept time.Duration
+			l := newLimiter(1000, []Option{WithSyscallCost(testc.cost)})
+			l.sleeper = sleepFunc(func(d time.Duration) { slept += d })
+			l.init()
+
+			if _, err := l.limit(100, 100); err != nil {
+				t.Fatal(err)
+			}
+			if slept < testc.wantMin || slept > testc.wantMax {
+				t.Errorf("Want sleep within %s..%s, got %s.", testc.wantMin, testc.wantMax, slept)
+			}
+		})
+	}
+}
+
+func TestInitialDebt(t *testing.T) {
+	t.Parallel()
+
+	// Each stream reads 100 bytes without limit after its debt. The data
+	// must not arrive before the debt has been paid.
+	debts := []time.Duration{0, 50 * time.Millisecond, 100 * time.Millisecond}
+	for _, debt := range debts {
+		debt := debt
+		t.Run(debt.String(), func(t *testing.T) {
+			t.Parallel()
+
+			src := bytes.NewReader(make([]byte, 100))
+			r := NewReader(src, 0, WithInitialDebt(debt))
+
+			start := time.Now()
+			if _, err := r.Read(make([]byte, 100)); err != nil {
+				t.Fatal(err)
+			}
+			if took := time.Since(start); took < debt || took > debt+50*time.Millisecond {
+				t.Errorf("Took %s, want %s.", took, debt)
+			}
+
+			// Only the first read pays the debt.
+			start = time.Now()
+			src.Reset(make([]byte, 100))
+			if _, err := r.Read(make([]byte, 100)); err != nil {
+				t.Fatal(err)
+			}
+			if took := time.Since(start); took > 20*time.Millisecond {
+				t.Errorf("Took %s on the second read, want no delay.", took)
+			}
+		})
+	}
+}
+
+func TestIsochronousMode(t *testing.T) {
+	t.Parallel()
+
+	l := newLimiter(0, []Option{WithIsochronousMode(100, 50*time.Millisecond)})
+
+	testt := []struct {
+		n       int
+		wantMin time.Duration
+		wantMax time.Duration
+	}{
+		// The first period starts with the first operation.
+		{60, 0, 0},
+		{60, 40 * time.Millisecond, 50 * time.Millisecond},
+		// The second period has 80 bytes left.
+		{50, 0, 0},
+		{30, 40 * time.Millisecond, 50 * time.Millisecond},
+		// Two periods at once.
+		{200, 90 * time.Millisecond, 100 * time.Millisecond},
+	}
+
+	for i, testc := range testt {
+		penalty, err := l.limit(testc.n, testc.n)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if penalty < testc.wantMin || penalty > testc.wantMax {
+			t.Errorf("Operation %d: want penalty within %s..%s, got %s.", i, testc.wantMin, testc.wantMax, penalty)
+		}
+	}
+
+	// Idle periods do not accumulate bytes.
+	time.Sleep(300 * time.Millisecond)
+	if penalty, _ := l.limit(60, 60); penalty != 0 {
+		t.Errorf("Want no penalty after idle periods, got %s.", penalty)
+	}
+	if penalty, _ := l.limit(60, 60); penalty <= 0 {
+		t.Error("Want penalty after using up the period, got none.")
+	}
+}
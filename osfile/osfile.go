@@ -0,0 +1,37 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package osfile provides bandwidth-limited access to operating system files.
+package osfile
+
+import (
+	"io"
+	"os"
+
+	"github.com/jwkohnen/bwio"
+)
+
+// NewReaderAt returns a new io.ReaderAt that reads from f and maintains the
+// given bandwidth across all concurrent ReadAt calls. On Linux, it reads
+// with pread(2) directly on the file descriptor, so parallel reads do not
+// contend on the file offset. Elsewhere it falls back to f.ReadAt. If
+// bandwidth is zero or negative, the reader will not limit.
+//
+// The file descriptor of f is put into blocking mode, see os.File.Fd. f must
+// stay open as long as the reader is used.
+func NewReaderAt(f *os.File, bandwidth int, opts ...bwio.Option) io.ReaderAt {
+	return bwio.NewReaderAt(newPreader(f), bandwidth, opts...)
+}
@@ -0,0 +1,76 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package osfile
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewReaderAt(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "bwio-osfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	data := make([]byte, 400)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if _, err := f.Write(data); err != nil {
+		t.Fatal(err)
+	}
+
+	// Four parallel reads of 100 bytes share 2000 B/s, which takes 200ms.
+	ra := NewReaderAt(f, 2000)
+	got := make([]byte, len(data))
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for off := 0; off < len(data); off += 100 {
+		wg.Add(1)
+		go func(off int) {
+			defer wg.Done()
+			if _, err := ra.ReadAt(got[off:off+100], int64(off)); err != nil {
+				t.Error(err)
+			}
+		}(off)
+	}
+	wg.Wait()
+	dur := time.Since(start)
+
+	if !bytes.Equal(got, data) {
+		t.Error("Want data read back unchanged.")
+	}
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+
+	n, err := ra.ReadAt(make([]byte, 10), 395)
+	if n != 5 || err != io.EOF {
+		t.Errorf("Want 5 bytes and %v at the end, got %d and %v.", io.EOF, n, err)
+	}
+}
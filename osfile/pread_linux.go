@@ -0,0 +1,60 @@
+//go:build linux
+// +build linux
+
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package osfile
+
+import (
+	"io"
+	"os"
+	"runtime"
+	"syscall"
+)
+
+// preader reads from a file with pread(2).
+type preader struct {
+	f  *os.File
+	fd int
+}
+
+func newPreader(f *os.File) io.ReaderAt {
+	return &preader{f: f, fd: int(f.Fd())}
+}
+
+// ReadAt implements the io.ReaderAt interface. Like os.File.ReadAt, it reads
+// until p is full and returns io.EOF if the file ends before.
+func (p *preader) ReadAt(b []byte, off int64) (n int, err error) {
+	defer runtime.KeepAlive(p.f)
+
+	for len(b) > 0 {
+		m, err := syscall.Pread(p.fd, b, off)
+		if err == syscall.EINTR {
+			continue
+		}
+		if err != nil {
+			return n, &os.PathError{Op: "pread", Path: p.f.Name(), Err: err}
+		}
+		if m == 0 {
+			return n, io.EOF
+		}
+		n += m
+		b = b[m:]
+		off += int64(m)
+	}
+	return n, nil
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "time"
+
+// Pacer is a custom rate calculation, e.g. a simulator of a specific network
+// link. *Limiter implements Pacer with the built-in calculation.
+type Pacer interface {
+	// Limit accounts for n bytes transferred with a buffer of bufSize
+	// bytes and returns how long to sleep before the next transfer. reset
+	// reports that the Pacer discarded its accumulated state, which is
+	// passed on to the reset callback as ResetPacer.
+	Limit(n, bufSize int) (sleep time.Duration, reset bool)
+}
+
+// WithPacer replaces the rate calculation of the limiter with p. The
+// bandwidth of the Reader or Writer and the options affecting the rate
+// calculation are ignored, whereas the sleeping, the stats and the reset
+// callback keep working. A nil Pacer disables limiting.
+func WithPacer(p Pacer) Option {
+	return func(l *limiter) {
+		if p == nil {
+			p = noPacer{}
+		}
+		l.pacer = p
+	}
+}
+
+// noPacer never limits.
+type noPacer struct{}
+
+func (noPacer) Limit(int, int) (time.Duration, bool) { return 0, false }
+
+// pace sleeps for the duration the Pacer returns.
+func (l *limiter) pace(n, bufSize int) (time.Duration, error) {
+	penalty, reset := l.pacer.Limit(n, bufSize)
+	if reset && l.onReset != nil {
+		l.onReset(ResetPacer)
+	}
+	return l.sleep(penalty)
+}
@@ -0,0 +1,94 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+// fixedPacer sleeps for a fixed duration per operation and reports a reset
+// on every other call.
+type fixedPacer struct {
+	sleep time.Duration
+	calls int
+}
+
+func (f *fixedPacer) Limit(n, bufSize int) (time.Duration, bool) {
+	f.calls++
+	return f.sleep, f.calls%2 == 0
+}
+
+func TestPacer(t *testing.T) {
+	t.Parallel()
+
+	p := &fixedPacer{sleep: 20 * time.Millisecond}
+	var resets int
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 1, WithPacer(p), WithOnReset(func(reason string) {
+		if reason == ResetPacer {
+			resets++
+		}
+	}))
+
+	start := time.Now()
+	n, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 100))
+	took := time.Since(start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1000 {
+		t.Errorf("Want %d bytes, got %d.", 1000, n)
+	}
+	// 10 reads at 20ms each, the bandwidth is ignored.
+	if took < 180*time.Millisecond || took > 350*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", took)
+	}
+	if resets != p.calls/2 {
+		t.Errorf("Want %d resets, got %d.", p.calls/2, resets)
+	}
+}
+
+func TestPacerNil(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 1, WithPacer(nil))
+
+	start := time.Now()
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took > 100*time.Millisecond {
+		t.Errorf("Took %s, want no limiting.", took)
+	}
+}
+
+func TestLimiterPacer(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 200)), 0, WithPacer(NewLimiter(1000)))
+
+	start := time.Now()
+	if _, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+	if took := time.Since(start); took < 150*time.Millisecond || took > 300*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", took)
+	}
+}
@@ -0,0 +1,96 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// maxInt is the largest value of type int.
+const maxInt = int(^uint(0) >> 1)
+
+// bandwidthUnits maps units to their value in bytes per second. Byte units
+// are binary like KBps, bit units are decimal as is the networking
+// convention.
+var bandwidthUnits = map[string]float64{
+	"":    1,
+	"B":   1,
+	"K":   KBps,
+	"KB":  KBps,
+	"KiB": KBps,
+	"M":   MBps,
+	"MB":  MBps,
+	"MiB": MBps,
+	"G":   GBps,
+	"GB":  GBps,
+	"GiB": GBps,
+
+	"b":    1.0 / 8,
+	"bit":  1.0 / 8,
+	"kb":   1e3 / 8,
+	"kbit": 1e3 / 8,
+	"Mb":   1e6 / 8,
+	"Mbit": 1e6 / 8,
+	"Gb":   1e9 / 8,
+	"Gbit": 1e9 / 8,
+}
+
+// ParseBandwidth parses a bandwidth like "512KB/s", "1.5 MBps" or
+// "100Mbit/s" and returns it in bytes per second. A plain number is taken as
+// bytes per second. The unit may end in "/s" or "ps". Byte units (B, KB, MB,
+// GB) are binary, i.e. 1 KB/s is 1024 bytes per second, while bit units (b,
+// kbit, Mbit, Gbit) are decimal, i.e. 1 kbit/s is 1000 bits per second. A
+// positive bandwidth is rounded up, so that it never turns into zero, i.e.
+// unlimited.
+func ParseBandwidth(s string) (int, error) {
+	unit := strings.TrimSpace(s)
+	switch {
+	case strings.HasSuffix(unit, "/s"):
+		unit = strings.TrimSuffix(unit, "/s")
+	case strings.HasSuffix(unit, "ps"):
+		unit = strings.TrimSuffix(unit, "ps")
+	}
+
+	i := strings.IndexFunc(unit, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	number := unit
+	if i >= 0 {
+		number, unit = unit[:i], strings.TrimSpace(unit[i:])
+	} else {
+		unit = ""
+	}
+
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return 0, fmt.Errorf("bwio: invalid bandwidth %q", s)
+	}
+	factor, ok := bandwidthUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("bwio: invalid bandwidth unit in %q", s)
+	}
+
+	bandwidth := math.Ceil(value * factor)
+	if bandwidth > float64(maxInt) {
+		return 0, fmt.Errorf("bwio: bandwidth %q out of range", s)
+	}
+
+	return int(bandwidth), nil
+}
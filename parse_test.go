@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "testing"
+
+func TestParseBandwidth(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		in   string
+		want int
+	}{
+		{"0", 0},
+		{"1000", 1000},
+		{"1000B", 1000},
+		{"1000 B/s", 1000},
+		{"1000Bps", 1000},
+		{"512KB/s", 512 * KBps},
+		{"512 KiB/s", 512 * KBps},
+		{"1.5MBps", 3 * MBps / 2},
+		{"2M", 2 * MBps},
+		{"1GB/s", GBps},
+		{"8bit/s", 1},
+		{"1bps", 1},
+		{"56kbit/s", 7000},
+		{"56kbps", 7000},
+		{"100Mbit/s", 12500000},
+		{"1 Gbps", 125000000},
+		{" 42 ", 42},
+	}
+	for _, testc := range testt {
+		got, err := ParseBandwidth(testc.in)
+		if err != nil {
+			t.Errorf("ParseBandwidth(%q): %v", testc.in, err)
+			continue
+		}
+		if got != testc.want {
+			t.Errorf("ParseBandwidth(%q): want %d, got %d.", testc.in, testc.want, got)
+		}
+	}
+}
+
+func TestParseBandwidthError(t *testing.T) {
+	t.Parallel()
+
+	for _, in := range []string{"", "fast", "-1", "1.2.3", "10 furlongs", "KB/s", "99999999999GB"} {
+		if got, err := ParseBandwidth(in); err == nil {
+			t.Errorf("ParseBandwidth(%q): want error, got %d.", in, got)
+		}
+	}
+}
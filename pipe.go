@@ -0,0 +1,50 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"errors"
+	"io"
+)
+
+// NewLimitedPipe creates a synchronous in-memory pipe like io.Pipe, except
+// that data flows from the writer to the reader at the given bandwidth. The
+// ends are plain *io.PipeReader and *io.PipeWriter, including CloseWithError:
+// closing one end propagates to the other just like with io.Pipe.
+//
+// Internally, a goroutine copies between two pipes through a Writer, so up
+// to 16 KiBytes may be in flight between the ends. Hence a Write may still
+// succeed shortly after the reader has been closed. If bandwidth is zero or
+// negative, the pipe will not limit.
+func NewLimitedPipe(bandwidth int) (*io.PipeReader, *io.PipeWriter) {
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+
+	go func() {
+		_, err := CopyBufferWriter(outW, inR, bandwidth, nil)
+		// Propagate the original error like io.Pipe would.
+		var bwErr *BwioError
+		if errors.As(err, &bwErr) {
+			err = bwErr.Cause
+		}
+		// On failure, tell both ends; on success, inR is drained anyway.
+		inR.CloseWithError(err)
+		outW.CloseWithError(err)
+	}()
+
+	return outR, inW
+}
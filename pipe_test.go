@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestLimitedPipe(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := NewLimitedPipe(1000)
+	go func() {
+		for i := 0; i < 4; i++ {
+			if _, err := pw.Write(make([]byte, 50)); err != nil {
+				t.Error(err)
+			}
+		}
+		pw.Close()
+	}()
+
+	// 200 bytes at 1000 B/s take 200ms.
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, pr)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 200 {
+		t.Errorf("Want 200 bytes, got %d.", n)
+	}
+	if dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestLimitedPipeCloseWithError(t *testing.T) {
+	t.Parallel()
+
+	pr, pw := NewLimitedPipe(0)
+	pw.CloseWithError(errPoison)
+	if _, err := pr.Read(make([]byte, 1)); err != errPoison {
+		t.Errorf("Want %v on read, got %v.", errPoison, err)
+	}
+
+	pr, pw = NewLimitedPipe(0)
+	pr.CloseWithError(errPoison)
+	// The first write may still be taken up by the copying goroutine.
+	_, err := pw.Write(make([]byte, 1))
+	if err == nil {
+		_, err = pw.Write(make([]byte, 1))
+	}
+	if err != errPoison {
+		t.Errorf("Want %v on write, got %v.", errPoison, err)
+	}
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Pool is a bandwidth budget shared by all Readers and Writers that join it
+// with WithPool. Unlike GlobalLimiter, which gives each active stream an
+// even share, a Pool accounts the bytes of all its streams in one bucket, so
+// a single busy stream may use the whole bandwidth. Each stream also
+// maintains its own bandwidth, if any; the stricter limit applies. A Pool is
+// safe for concurrent use.
+//
+// A Pool also dispenses its bandwidth as tokens, one per byte, see WaitN.
+type Pool struct {
+	mu  sync.Mutex // guards lim, except for its bandwidth
+	lim *limiter
+
+	tokensMu   sync.Mutex     // guards waiters and dispensing
+	waiters    []*tokenWaiter // FIFO
+	dispensing bool           // whether dispense is running
+}
+
+// tokenWaiter is a pending WaitN call. ready is closed once the tokens have
+// been granted.
+type tokenWaiter struct {
+	n     int
+	ready chan struct{}
+}
+
+// NewPool returns a new Pool that maintains the given bandwidth across all of
+// its streams. If bandwidth is zero or negative, the Pool will not limit.
+// The options configure the shared limiter, e.g. WithSlack.
+func NewPool(bandwidth int, opts ...Option) *Pool {
+	return &Pool{lim: newLimiter(float64(bandwidth), opts)}
+}
+
+// WithPool makes a Reader or Writer draw from the bandwidth of p in addition
+// to its own.
+func WithPool(p *Pool) Option {
+	return func(l *limiter) { l.pool = p }
+}
+
+// SetBandwidth changes the bandwidth of the Pool. If bandwidth is zero or
+// negative, the Pool will not limit.
+func (p *Pool) SetBandwidth(bandwidth int) {
+	p.lim.setBandwidth(float64(bandwidth))
+}
+
+// Bandwidth returns the bandwidth of the Pool.
+func (p *Pool) Bandwidth() int {
+	return p.lim.bandwidthInt()
+}
+
+// computePenalty accounts for n bytes transferred by one of the streams and
+// returns the penalty that stream has to sleep for.
+func (p *Pool) computePenalty(n, bufSize int) time.Duration {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lim.init()
+	return p.lim.computePenalty(n, bufSize)
+}
+
+// WaitN blocks until the Pool has dispensed n tokens, i.e. bytes, to the
+// caller or ctx is done. The tokens are handed out in FIFO order by a
+// goroutine that runs as long as there are waiters. Unlike WithPool, callers
+// do not contend on a mutex while they wait, so e.g. the ReadAt calls of a
+// ReaderAt created with WithXRateLimiter(p) proceed concurrently up to the
+// bandwidth. Like the limiter, the Pool grants a request once it has paid
+// off the previous ones, so a request of any size is eventually granted. If
+// the bandwidth of the Pool is zero or negative, WaitN returns immediately.
+func (p *Pool) WaitN(ctx context.Context, n int) error {
+	if n <= 0 || p.lim.getBandwidth() <= 0 {
+		return nil
+	}
+
+	w := &tokenWaiter{n: n, ready: make(chan struct{})}
+	p.tokensMu.Lock()
+	p.waiters = append(p.waiters, w)
+	if !p.dispensing {
+		p.dispensing = true
+		go p.dispense()
+	}
+	p.tokensMu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	p.tokensMu.Lock()
+	defer p.tokensMu.Unlock()
+	for i, other := range p.waiters {
+		if other == w {
+			p.waiters = append(p.waiters[:i], p.waiters[i+1:]...)
+			return ctx.Err()
+		}
+	}
+	// The tokens were granted in the meantime.
+	return nil
+}
+
+// Burst implements RateLimiter. It returns zero, because WaitN accepts any
+// number of tokens.
+func (p *Pool) Burst() int {
+	return 0
+}
+
+// dispense hands out tokens to the waiters at the bandwidth of the Pool. It
+// returns once there are no waiters left and the tokens granted have been
+// paid off, so that a new dispense does not start with a debt forgiven.
+func (p *Pool) dispense() {
+	ticker := time.NewTicker(defaultTickInterval)
+	defer ticker.Stop()
+
+	var tokens float64
+	last := time.Now()
+	for {
+		p.tokensMu.Lock()
+		bandwidth := p.lim.getBandwidth()
+		for len(p.waiters) > 0 && (tokens >= 0 || bandwidth <= 0) {
+			w := p.waiters[0]
+			p.waiters = p.waiters[1:]
+			tokens -= float64(w.n)
+			close(w.ready)
+		}
+		if len(p.waiters) == 0 && (tokens >= 0 || bandwidth <= 0) {
+			p.dispensing = false
+			p.tokensMu.Unlock()
+			return
+		}
+		p.tokensMu.Unlock()
+
+		now := <-ticker.C
+		tokens += bandwidth * now.Sub(last).Seconds()
+		last = now
+	}
+}
+
+// globalPool is the package-level Pool of WithGlobal.
+var globalPool = NewPool(0)
+
+// SetGlobalBandwidth sets the bandwidth of the package-level Pool that all
+// Readers and Writers created with WithGlobal share. This is a shortcut for
+// programs that just want to limit all their I/O, e.g.
+//
+//	bwio.SetGlobalBandwidth(10 * bwio.MBps)
+//	r := bwio.NewReader(src, 0, bwio.WithGlobal())
+//
+// If bandwidth is zero or negative, which is the default, the package-level
+// Pool does not limit. SetGlobalBandwidth also restarts the accounting of the
+// package-level Pool, so neither credit nor debt carries over from the
+// previous bandwidth.
+func SetGlobalBandwidth(bandwidth int) {
+	globalPool.mu.Lock()
+	globalPool.lim.restart()
+	globalPool.mu.Unlock()
+
+	globalPool.SetBandwidth(bandwidth)
+}
+
+// GetGlobalBandwidth returns the bandwidth of the package-level Pool.
+func GetGlobalBandwidth() int {
+	return globalPool.Bandwidth()
+}
+
+// WithGlobal makes a Reader or Writer draw from the package-level Pool
+// configured with SetGlobalBandwidth.
+func WithGlobal() Option {
+	return WithPool(globalPool)
+}
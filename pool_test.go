@@ -0,0 +1,146 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPool(t *testing.T) {
+	t.Parallel()
+
+	// Two streams of 100 bytes share 1000 B/s, which takes 200ms.
+	p := NewPool(1000)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src := &chunkReader{r: bytes.NewReader(make([]byte, 100)), size: 10}
+			r := NewReader(src, 0, WithPool(p))
+			if _, err := io.Copy(ioutil.Discard, r); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dur := time.Since(start); dur < 150*time.Millisecond || dur > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestPoolStricterStream(t *testing.T) {
+	t.Parallel()
+
+	// The stream's own 1000 B/s are stricter than the pool's.
+	p := NewPool(1 << 20)
+	r := NewReader(bytes.NewReader(make([]byte, 100)), 1000, WithPool(p))
+
+	start := time.Now()
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Error(err)
+	}
+	if dur := time.Since(start); dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
+
+func TestPoolWaitN(t *testing.T) {
+	t.Parallel()
+
+	// The first 100 bytes are granted immediately, the other four take
+	// 50ms each at 2000 B/s.
+	p := NewPool(2000)
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := p.WaitN(context.Background(), 100); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if dur := time.Since(start); dur < 150*time.Millisecond || dur > 350*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+}
+
+func TestPoolWaitNCanceled(t *testing.T) {
+	t.Parallel()
+
+	p := NewPool(1000)
+	if err := p.WaitN(context.Background(), 1000); err != nil {
+		t.Fatal(err)
+	}
+
+	// The debt of 1000 bytes takes a second to pay off.
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := p.WaitN(ctx, 100); err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestPoolUnlimitedWaitN(t *testing.T) {
+	t.Parallel()
+
+	p := NewPool(0)
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if err := p.WaitN(context.Background(), 1<<20); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dur := time.Since(start); dur > 50*time.Millisecond {
+		t.Errorf("Took %s, want no limiting.", dur)
+	}
+}
+
+func TestGlobalBandwidth(t *testing.T) {
+	if got := GetGlobalBandwidth(); got != 0 {
+		t.Errorf("Want global bandwidth 0 by default, got %d.", got)
+	}
+
+	SetGlobalBandwidth(1000)
+	defer SetGlobalBandwidth(0)
+	if got := GetGlobalBandwidth(); got != 1000 {
+		t.Errorf("Want global bandwidth 1000, got %d.", got)
+	}
+
+	r := NewReader(bytes.NewReader(make([]byte, 100)), 0, WithGlobal())
+	start := time.Now()
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Error(err)
+	}
+	if dur := time.Since(start); dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
@@ -0,0 +1,44 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "math"
+
+// WithBandwidth overrides the bandwidth passed to the constructor. It is
+// mostly useful with the presets below, e.g. NewReader(r, 0, T1).
+func WithBandwidth(bandwidth int) Option {
+	return func(l *limiter) {
+		l.bandwidth = math.Float64bits(float64(bandwidth))
+	}
+}
+
+// Presets of common link speeds for demos and tests, e.g. to simulate a modem
+// connection with NewReader(r, 0, Modem56k). The link speeds are given in bits
+// per second and converted with BitsPerSecond.
+var (
+	// Modem56k is a 56 kbit/s dial-up modem, i.e. 7000 bytes per second.
+	Modem56k = WithBandwidth(BitsPerSecond(56000))
+	// DSL1M is a 1 Mbit/s DSL line, i.e. 125000 bytes per second.
+	DSL1M = WithBandwidth(BitsPerSecond(1000000))
+	// T1 is a 1.544 Mbit/s T1 line, i.e. 193000 bytes per second.
+	T1 = WithBandwidth(BitsPerSecond(1544000))
+	// Cable10M is a 10 Mbit/s cable connection, i.e. 1250000 bytes per
+	// second.
+	Cable10M = WithBandwidth(BitsPerSecond(10000000))
+	// Gigabit is a 1 Gbit/s Ethernet link, i.e. 125000000 bytes per second.
+	Gigabit = WithBandwidth(BitsPerSecond(1000000000))
+)
@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestPresets(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name   string
+		preset Option
+		want   float64
+	}{
+		{"Modem56k", Modem56k, 7000},
+		{"DSL1M", DSL1M, 125000},
+		{"T1", T1, 193000},
+		{"Cable10M", Cable10M, 1250000},
+		{"Gigabit", Gigabit, 125000000},
+	}
+
+	for _, testc := range testt {
+		r := NewReader(new(bytes.Buffer), 1, testc.preset)
+		if got := r.lim.getBandwidth(); got != testc.want {
+			t.Errorf("%s: want bandwidth %f, got %f.", testc.name, testc.want, got)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// CopyProgress copies the same way Copy does and calls progress after each
+// buffer sized chunk that has been written to dst. progress receives the
+// number of bytes written so far and the total number of bytes to copy, or
+// -1 if the total is unknown. The total is known if src has a Len method,
+// like bytes.Reader, or if src is an io.Seeker, like os.File.
+func CopyProgress(dst io.Writer, src io.Reader, bandwidth int, progress func(written, total int64)) (written int64, err error) {
+	pw := &progressWriter{
+		dst:      dst,
+		total:    remaining(src),
+		progress: progress,
+	}
+	return CopyBuffer(pw, src, bandwidth, nil)
+}
+
+// progressWriter reports the progress of each write.
+type progressWriter struct {
+	dst      io.Writer
+	written  int64
+	total    int64
+	progress func(written, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (n int, err error) {
+	n, err = w.dst.Write(p)
+	w.written += int64(n)
+	if n > 0 && w.progress != nil {
+		w.progress(w.written, w.total)
+	}
+	return n, err
+}
+
+// remaining returns the number of bytes left to read from src, or -1 if
+// that is unknown.
+func remaining(src io.Reader) int64 {
+	switch s := src.(type) {
+	case interface{ Len() int }:
+		return int64(s.Len())
+	case io.Seeker:
+		cur, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		end, err := s.Seek(0, io.SeekEnd)
+		if err != nil {
+			return -1
+		}
+		if _, err := s.Seek(cur, io.SeekStart); err != nil {
+			return -1
+		}
+		return end - cur
+	}
+	return -1
+}
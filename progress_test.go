@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestCopyProgress(t *testing.T) {
+	t.Parallel()
+
+	f, err := ioutil.TempFile("", "bwio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+	if _, err := f.Write(make([]byte, 40000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.Seek(8000, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	type progress struct{ written, total int64 }
+	testt := []struct {
+		name string
+		src  io.Reader
+		want []progress
+	}{
+		{"len", bytes.NewReader(make([]byte, 20000)), []progress{{16 << 10, 20000}, {20000, 20000}}},
+		{"seeker", f, []progress{{16 << 10, 32000}, {32000, 32000}}},
+		{"unknown", io.LimitReader(bytes.NewReader(make([]byte, 20000)), 20000), []progress{{16 << 10, -1}, {20000, -1}}},
+	}
+	for _, testc := range testt {
+		t.Run(testc.name, func(t *testing.T) {
+			var got []progress
+			_, err := CopyProgress(ioutil.Discard, testc.src, 0, func(written, total int64) {
+				got = append(got, progress{written, total})
+			})
+			if err != nil {
+				t.Error(err)
+			}
+			if !reflect.DeepEqual(got, testc.want) {
+				t.Errorf("Want progress %v, got %v.", testc.want, got)
+			}
+		})
+	}
+}
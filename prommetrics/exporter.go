@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prommetrics
+
+import (
+	"sync"
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Exporter exports the stats of bwio readers and writers as the metrics
+// bwio_bytes_total, bwio_sleep_seconds_total and bwio_rate_bytes_per_second.
+// The label direction is either "read" or "write"; callers may add their own
+// labels, e.g. to distinguish connections.
+type Exporter struct {
+	interval time.Duration
+	bytes    *prometheus.CounterVec
+	sleep    *prometheus.CounterVec
+	rate     *prometheus.GaugeVec
+}
+
+// NewExporter returns a new Exporter that registers its metrics with reg.
+// The metrics are updated every interval and carry the label direction plus
+// the given labelNames.
+func NewExporter(reg prometheus.Registerer, interval time.Duration, labelNames ...string) (*Exporter, error) {
+	labels := append([]string{"direction"}, labelNames...)
+	e := &Exporter{
+		interval: interval,
+		bytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bwio_bytes_total",
+			Help: "Number of bytes transferred.",
+		}, labels),
+		sleep: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "bwio_sleep_seconds_total",
+			Help: "Time slept to maintain the bandwidth.",
+		}, labels),
+		rate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "bwio_rate_bytes_per_second",
+			Help: "Rate achieved within the last update interval.",
+		}, labels),
+	}
+
+	for _, c := range []prometheus.Collector{e.bytes, e.sleep, e.rate} {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// statser is implemented by bwio.Reader and bwio.Writer.
+type statser interface {
+	Stats() bwio.Stats
+}
+
+// WatchReader starts a goroutine that exports the stats of r with the given
+// label values. The returned function stops the goroutine after a final
+// update.
+func (e *Exporter) WatchReader(r *bwio.Reader, labelValues ...string) (stop func()) {
+	return e.watch(r, "read", labelValues)
+}
+
+// WatchWriter starts a goroutine that exports the stats of w with the given
+// label values. The returned function stops the goroutine after a final
+// update.
+func (e *Exporter) WatchWriter(w *bwio.Writer, labelValues ...string) (stop func()) {
+	return e.watch(w, "write", labelValues)
+}
+
+func (e *Exporter) watch(s statser, direction string, labelValues []string) func() {
+	values := append([]string{direction}, labelValues...)
+	bytes := e.bytes.WithLabelValues(values...)
+	sleep := e.sleep.WithLabelValues(values...)
+	rate := e.rate.WithLabelValues(values...)
+
+	var (
+		last     bwio.Stats
+		lastTime = time.Now()
+	)
+	update := func() {
+		stats, now := s.Stats(), time.Now()
+
+		// The stats start over after Reset, but counters must not
+		// decrease.
+		if stats.BytesTransferred < last.BytesTransferred || stats.Sleep < last.Sleep {
+			last = bwio.Stats{}
+		}
+		n := stats.BytesTransferred - last.BytesTransferred
+		bytes.Add(float64(n))
+		sleep.Add((stats.Sleep - last.Sleep).Seconds())
+		if d := now.Sub(lastTime); d > 0 {
+			rate.Set(float64(n) / d.Seconds())
+		}
+
+		last, lastTime = stats, now
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				update()
+			case <-done:
+				update()
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			<-stopped
+		})
+	}
+}
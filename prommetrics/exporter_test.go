@@ -0,0 +1,61 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prommetrics
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestExporter(t *testing.T) {
+	t.Parallel()
+
+	reg := prometheus.NewRegistry()
+	e, err := NewExporter(reg, 10*time.Millisecond, "conn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewExporter(reg, time.Second, "conn"); err == nil {
+		t.Error("Want error on duplicate registration.")
+	}
+
+	// Reading 200 bytes at 1000 B/s sleeps for about 200ms.
+	r := bwio.NewReader(bytes.NewReader(make([]byte, 200)), 1000)
+	stop := e.WatchReader(r, "a")
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	stop()
+	stop()
+
+	if got := testutil.ToFloat64(e.bytes.WithLabelValues("read", "a")); got != 200 {
+		t.Errorf("Want 200 bytes, got %f.", got)
+	}
+	if got := testutil.ToFloat64(e.sleep.WithLabelValues("read", "a")); got < 0.15 || got > 0.4 {
+		t.Errorf("Want about 0.2s of sleep, got %f.", got)
+	}
+	if got := testutil.CollectAndCount(e.rate); got != 1 {
+		t.Errorf("Want 1 rate series, got %d.", got)
+	}
+}
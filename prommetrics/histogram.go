@@ -0,0 +1,58 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package prommetrics exports metrics of bwio readers and writers to
+// Prometheus.
+package prommetrics
+
+import (
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DefaultReadBytesBuckets are the buckets of NewReadBytesHistogram: 512 B,
+// 4 KiB, 64 KiB, 1 MiB and 10 MiB.
+var DefaultReadBytesBuckets = []float64{512, 4 << 10, 64 << 10, 1 << 20, 10 << 20}
+
+// NewReadBytesHistogram returns a new histogram named
+// bwio_read_bytes_histogram for the number of bytes returned by each Read.
+// The distribution reveals whether callers use small or large buffers, which
+// affects the stall detection and the granularity of the limiter. If buckets
+// is empty, DefaultReadBytesBuckets are used. The histogram still needs to
+// be registered.
+func NewReadBytesHistogram(buckets []float64) prometheus.Histogram {
+	if len(buckets) == 0 {
+		buckets = DefaultReadBytesBuckets
+	}
+	return prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bwio_read_bytes_histogram",
+		Help:    "Number of bytes returned by each Read of a bwio.Reader.",
+		Buckets: buckets,
+	})
+}
+
+// ObserveReads returns an option that observes the number of bytes of each
+// Read in h. It is implemented as an operation logger, so it replaces any
+// other logger configured with bwio.WithOperationLogger.
+func ObserveReads(h prometheus.Observer) bwio.Option {
+	return bwio.WithOperationLogger(func(op string, n int, _ time.Duration, _ time.Time) {
+		if op == "read" {
+			h.Observe(float64(n))
+		}
+	})
+}
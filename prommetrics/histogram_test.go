@@ -0,0 +1,59 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prommetrics
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestReadBytesHistogram(t *testing.T) {
+	t.Parallel()
+
+	h := NewReadBytesHistogram(nil)
+	r := bwio.NewReader(bytes.NewReader(make([]byte, 5000)), 0, ObserveReads(h))
+	if _, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 1000)); err != nil {
+		t.Fatal(err)
+	}
+
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	hist := m.GetHistogram()
+
+	// Five reads of 1000 bytes and a final read of 0 bytes at EOF.
+	if got := hist.GetSampleCount(); got != 6 {
+		t.Errorf("Want 6 reads, got %d.", got)
+	}
+	if got := hist.GetSampleSum(); got != 5000 {
+		t.Errorf("Want 5000 bytes, got %f.", got)
+	}
+
+	want := []uint64{1, 6, 6, 6, 6}
+	for i, b := range hist.GetBucket() {
+		if got := b.GetCumulativeCount(); got != want[i] {
+			t.Errorf("Bucket %f: want %d reads, got %d.", b.GetUpperBound(), want[i], got)
+		}
+	}
+}
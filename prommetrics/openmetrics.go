@@ -0,0 +1,45 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prommetrics
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+
+	"github.com/jwkohnen/bwio"
+)
+
+var metricName = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// WriteOpenMetrics writes the achieved bandwidth of r as a gauge with the
+// given metric name in the OpenMetrics text format, including the HELP, TYPE
+// and EOF lines. It does not need a registry, e.g. to write the metrics to a
+// file or serve them with a simple HTTP handler.
+func WriteOpenMetrics(w io.Writer, r *bwio.Reader, name string) error {
+	if !metricName.MatchString(name) {
+		return fmt.Errorf("prommetrics: invalid metric name %q", name)
+	}
+
+	value := strconv.FormatFloat(r.Stats().AchievedBandwidth, 'g', -1, 64)
+	_, err := fmt.Fprintf(w, "# HELP %[1]s Achieved bandwidth in bytes per second.\n"+
+		"# TYPE %[1]s gauge\n"+
+		"%[1]s %[2]s\n"+
+		"# EOF\n", name, value)
+	return err
+}
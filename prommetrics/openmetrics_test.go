@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package prommetrics
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/jwkohnen/bwio"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	t.Parallel()
+
+	// Reading 200 bytes at 1000 B/s achieves about 1000 B/s.
+	r := bwio.NewReader(bytes.NewReader(make([]byte, 200)), 1000)
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteOpenMetrics(&buf, r, "bwio_achieved_bandwidth"); err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(buf.String(), "\n")
+	want := []string{
+		"# HELP bwio_achieved_bandwidth Achieved bandwidth in bytes per second.",
+		"# TYPE bwio_achieved_bandwidth gauge",
+		"",
+		"# EOF",
+		"",
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("Want %d lines, got %q.", len(want), buf.String())
+	}
+	for i, line := range want {
+		if i != 2 && lines[i] != line {
+			t.Errorf("Line %d: want %q, got %q.", i+1, line, lines[i])
+		}
+	}
+
+	sample := strings.Fields(lines[2])
+	if len(sample) != 2 || sample[0] != "bwio_achieved_bandwidth" {
+		t.Fatalf("Want a sample of bwio_achieved_bandwidth, got %q.", lines[2])
+	}
+	if got, err := strconv.ParseFloat(sample[1], 64); err != nil || got < 500 || got > 1500 {
+		t.Errorf("Want about 1000 B/s, got %q.", sample[1])
+	}
+}
+
+func TestWriteOpenMetricsInvalidName(t *testing.T) {
+	t.Parallel()
+
+	r := bwio.NewReader(bytes.NewReader(nil), 0)
+	if err := WriteOpenMetrics(ioutil.Discard, r, "bwio-bandwidth"); err == nil {
+		t.Error("Want error on invalid metric name.")
+	}
+}
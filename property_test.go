@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/leanovate/gopter"
+	"github.com/leanovate/gopter/gen"
+	"github.com/leanovate/gopter/prop"
+)
+
+func TestPropertyAchievedRate(t *testing.T) {
+	t.Parallel()
+
+	parameters := gopter.DefaultTestParameters()
+	parameters.MinSuccessfulTests = 20
+	if testing.Short() {
+		parameters.MinSuccessfulTests = 5
+	}
+
+	properties := gopter.NewProperties(parameters)
+	properties.Property("achieved rate does not exceed the bandwidth", prop.ForAll(
+		func(bandwidth, bufSize, millis int) bool {
+			// Transfer for at most 200ms.
+			dataSize := bandwidth * millis / 1000
+			if dataSize == 0 {
+				dataSize = 1
+			}
+
+			br := NewReader(bytes.NewReader(make([]byte, dataSize)), bandwidth)
+			start := time.Now()
+			// Hide WriteTo of br and ReadFrom of ioutil.Discard, so
+			// that all data flows through a buffer of bufSize bytes.
+			n, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{br}, make([]byte, bufSize))
+			dur := time.Since(start)
+			if err != nil || n != int64(dataSize) {
+				return false
+			}
+
+			achievedRate := float64(n) / dur.Seconds()
+			return achievedRate <= float64(bandwidth)*1.05
+		},
+		gen.IntRange(1<<10, 10<<20),
+		gen.IntRange(1, 64<<10),
+		gen.IntRange(1, 200),
+	))
+	properties.TestingRun(t)
+}
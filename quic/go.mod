@@ -0,0 +1,14 @@
+module github.com/jwkohnen/bwio/quic
+
+go 1.26.0
+
+require (
+	github.com/jwkohnen/bwio v0.1.0
+	github.com/quic-go/quic-go v0.63.0
+)
+
+require (
+	golang.org/x/crypto v0.54.0 // indirect
+	golang.org/x/net v0.56.0 // indirect
+	golang.org/x/sys v0.47.0 // indirect
+)
@@ -0,0 +1,103 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package quic limits the bandwidth of QUIC streams of quic-go. QUIC streams
+// have their own flow control; this package adds application level
+// throttling on top.
+package quic
+
+import (
+	"context"
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/quic-go/quic-go"
+)
+
+// Stream wraps a *quic.Stream and maintains independent bandwidths for
+// reading and writing. quic-go implements streams as a concrete type rather
+// than an interface, so Stream provides the same methods instead. Methods
+// that would bypass the limiters, like Peek or TryWriteAll, are deliberately
+// not forwarded.
+type Stream struct {
+	s *quic.Stream
+	r *bwio.Reader
+	w *bwio.Writer
+}
+
+// NewStream returns a new Stream that wraps s and limits reads to readBW and
+// writes to writeBW bytes per second. If a bandwidth is zero or negative, the
+// respective direction is not limited.
+func NewStream(s *quic.Stream, readBW, writeBW int) *Stream {
+	return &Stream{
+		s: s,
+		r: bwio.NewReader(s, readBW),
+		w: bwio.NewWriter(s, writeBW),
+	}
+}
+
+// Read reads from the stream and maintains the read bandwidth.
+func (s *Stream) Read(p []byte) (int, error) {
+	return s.r.Read(p)
+}
+
+// Write writes to the stream and maintains the write bandwidth.
+func (s *Stream) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+// StreamID returns the stream ID.
+func (s *Stream) StreamID() quic.StreamID {
+	return s.s.StreamID()
+}
+
+// CancelRead aborts receiving on the stream.
+func (s *Stream) CancelRead(errorCode quic.StreamErrorCode) {
+	s.s.CancelRead(errorCode)
+}
+
+// CancelWrite aborts sending on the stream.
+func (s *Stream) CancelWrite(errorCode quic.StreamErrorCode) {
+	s.s.CancelWrite(errorCode)
+}
+
+// Close closes the write direction of the stream.
+func (s *Stream) Close() error {
+	return s.s.Close()
+}
+
+// Context returns a context that is cancelled once the write direction of
+// the stream is closed.
+func (s *Stream) Context() context.Context {
+	return s.s.Context()
+}
+
+// SetReadDeadline sets the deadline for future and pending Read calls. The
+// penalty sleeps of the limiter do not respect the deadline.
+func (s *Stream) SetReadDeadline(t time.Time) error {
+	return s.s.SetReadDeadline(t)
+}
+
+// SetWriteDeadline sets the deadline for future and pending Write calls. The
+// penalty sleeps of the limiter do not respect the deadline.
+func (s *Stream) SetWriteDeadline(t time.Time) error {
+	return s.s.SetWriteDeadline(t)
+}
+
+// SetDeadline sets the read and write deadlines.
+func (s *Stream) SetDeadline(t time.Time) error {
+	return s.s.SetDeadline(t)
+}
@@ -0,0 +1,127 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package quic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+)
+
+const alpn = "bwio-test"
+
+func serverTLSConfig(t *testing.T) *tls.Config {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		NextProtos:   []string{alpn},
+	}
+}
+
+func TestStream(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	ln, err := quic.ListenAddr("127.0.0.1:0", serverTLSConfig(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	// The server sends 2000 bytes at 10000 B/s, which takes 200ms.
+	errc := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept(ctx)
+		if err != nil {
+			errc <- err
+			return
+		}
+		qs, err := conn.AcceptStream(ctx)
+		if err != nil {
+			errc <- err
+			return
+		}
+		s := NewStream(qs, 0, 10000)
+		if _, err := io.Copy(ioutil.Discard, io.LimitReader(s, 1)); err != nil {
+			errc <- err
+			return
+		}
+		if _, err := s.Write(make([]byte, 2000)); err != nil {
+			errc <- err
+			return
+		}
+		errc <- s.Close()
+	}()
+
+	conn, err := quic.DialAddr(ctx, ln.Addr().String(), &tls.Config{InsecureSkipVerify: true, NextProtos: []string{alpn}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.CloseWithError(0, "")
+
+	qs, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewStream(qs, 0, 0)
+	if _, err := s.Write([]byte{0}); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, s)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 2000 {
+		t.Errorf("Want 2000 bytes, got %d.", n)
+	}
+	if dur < 150*time.Millisecond || dur > time.Second {
+		t.Errorf("Took %s, want 200ms.", dur)
+	}
+	if err := <-errc; err != nil {
+		t.Error(err)
+	}
+}
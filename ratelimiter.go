@@ -0,0 +1,63 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"time"
+)
+
+// RateLimiter is the subset of the methods of *rate.Limiter of
+// golang.org/x/time/rate that WithXRateLimiter needs. The interface keeps
+// this package free of the dependency.
+type RateLimiter interface {
+	// WaitN blocks until n tokens are available or ctx is done.
+	WaitN(ctx context.Context, n int) error
+	// Burst returns the maximum number of tokens WaitN accepts at once.
+	Burst() int
+}
+
+// WithXRateLimiter replaces the rate calculation of the limiter with calls
+// to rl.WaitN, one token per byte, e.g.
+//
+//	rl := rate.NewLimiter(rate.Limit(MBps), 64*KBps)
+//	r := NewReader(src, 0, WithXRateLimiter(rl))
+//
+// This lets unrelated parts of a program share a single *rate.Limiter. The
+// bandwidth of the Reader or Writer and the options affecting the rate
+// calculation are ignored. Operations larger than the burst of rl wait for
+// several chunks of tokens.
+func WithXRateLimiter(rl RateLimiter) Option {
+	return func(l *limiter) { l.rateLimiter = rl }
+}
+
+// waitRate waits for n tokens of the rate limiter and returns the time
+// waited.
+func (l *limiter) waitRate(n int) (time.Duration, error) {
+	start := time.Now()
+	for n > 0 {
+		chunk := n
+		if burst := l.rateLimiter.Burst(); burst > 0 && chunk > burst {
+			chunk = burst
+		}
+		if err := l.rateLimiter.WaitN(l.ctx, chunk); err != nil {
+			return time.Since(start), err
+		}
+		n -= chunk
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRateLimiter mimics *rate.Limiter without burst credits: WaitN sleeps
+// for n/rate and rejects n above burst.
+type fakeRateLimiter struct {
+	rate  int
+	burst int
+
+	mu    sync.Mutex
+	waits []int
+}
+
+func (f *fakeRateLimiter) WaitN(ctx context.Context, n int) error {
+	if n > f.burst {
+		return errPoison
+	}
+	f.mu.Lock()
+	f.waits = append(f.waits, n)
+	f.mu.Unlock()
+
+	timer := time.NewTimer(time.Duration(n) * time.Second / time.Duration(f.rate))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (f *fakeRateLimiter) Burst() int {
+	return f.burst
+}
+
+func TestXRateLimiter(t *testing.T) {
+	t.Parallel()
+
+	rl := &fakeRateLimiter{rate: 1000, burst: 100}
+
+	// The bandwidth is ignored in favour of rl: 250 bytes take 250ms.
+	r := NewReader(bytes.NewReader(make([]byte, 250)), 1, WithXRateLimiter(rl))
+
+	start := time.Now()
+	n, err := io.Copy(ioutil.Discard, r)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 250 {
+		t.Errorf("Want 250 bytes, got %d.", n)
+	}
+	if dur < 200*time.Millisecond || dur > 500*time.Millisecond {
+		t.Errorf("Took %s, want 250ms.", dur)
+	}
+	if len(rl.waits) != 3 {
+		t.Errorf("Want 3 waits in chunks of the burst, got %v.", rl.waits)
+	}
+}
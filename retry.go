@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// RetryWriter wraps another writer, maintains a given bandwidth and retries
+// writes that fail transiently with io.ErrClosedPipe or io.ErrUnexpectedEOF,
+// e.g. on HTTP/2 streams during congestion.
+type RetryWriter struct {
+	w          *Writer
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewRetryWriter returns a new RetryWriter that wraps w and maintains the
+// given bandwidth. A failed write is retried up to maxRetries times after a
+// delay of backoff each; bytes written before the failure are not written
+// again. If bandwidth is zero or negative, the RetryWriter will not limit.
+func NewRetryWriter(w io.Writer, bandwidth, maxRetries int, backoff time.Duration, opts ...Option) *RetryWriter {
+	return &RetryWriter{
+		w:          NewWriter(w, bandwidth, opts...),
+		maxRetries: maxRetries,
+		backoff:    backoff,
+	}
+}
+
+// Write implements the io.Writer interface and maintains the given
+// bandwidth. It returns the error of the last attempt once the retries are
+// exhausted, or any other error immediately.
+func (rw *RetryWriter) Write(p []byte) (n int, err error) {
+	for retries := 0; ; retries++ {
+		var m int
+		m, err = rw.w.Write(p[n:])
+		n += m
+		if err == nil || !isTransient(err) || retries >= rw.maxRetries {
+			return n, err
+		}
+
+		if err := rw.w.lim.sleeper.Sleep(rw.w.lim.ctx, rw.backoff); err != nil {
+			return n, err
+		}
+	}
+}
+
+// isTransient reports whether a write that failed with err may be retried.
+func isTransient(err error) bool {
+	return errors.Is(err, io.ErrClosedPipe) || errors.Is(err, io.ErrUnexpectedEOF)
+}
@@ -0,0 +1,77 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// flakyWriter fails as many calls as failures with err, writing one byte
+// each, before it writes successfully.
+type flakyWriter struct {
+	bytes.Buffer
+	failures int
+	err      error
+}
+
+func (w *flakyWriter) Write(p []byte) (int, error) {
+	if w.failures > 0 {
+		w.failures--
+		n, _ := w.Buffer.Write(p[:1])
+		return n, w.err
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestRetryWriter(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name     string
+		failures int
+		err      error
+		wantErr  error
+		wantData string
+	}{
+		{"closed pipe", 2, io.ErrClosedPipe, nil, "hello"},
+		{"unexpected EOF", 2, io.ErrUnexpectedEOF, nil, "hello"},
+		{"exhausted", 4, io.ErrClosedPipe, io.ErrClosedPipe, "hel"},
+		{"permanent", 1, errPoison, errPoison, "h"},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			fw := &flakyWriter{failures: testc.failures, err: testc.err}
+			rw := NewRetryWriter(fw, 0, 2, 10*time.Millisecond)
+
+			n, err := rw.Write([]byte("hello"))
+			if !errors.Is(err, testc.wantErr) {
+				t.Errorf("Want %v, got %v.", testc.wantErr, err)
+			}
+			if n != len(testc.wantData) || fw.String() != testc.wantData {
+				t.Errorf("Want %q, got %q (%d bytes).", testc.wantData, fw.String(), n)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestSharedLimiterScaling does not run in parallel, because it measures the
+// rates of up to 64 goroutines sharing one Pool closely. The aggregate rate
+// must stay within 10% of the bandwidth and each goroutine must get its fair
+// share within 20%.
+func TestSharedLimiterScaling(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+
+	const (
+		bandwidth = MBps
+		bufSize   = 512
+		duration  = time.Second
+	)
+
+	for _, n := range []int{1, 2, 4, 8, 16, 32, 64} {
+		t.Run(fmt.Sprintf("goroutines=%d", n), func(t *testing.T) {
+			p := NewPool(bandwidth)
+			counts := make([]int64, n)
+
+			start := time.Now()
+			deadline := start.Add(duration)
+			var wg sync.WaitGroup
+			for i := range counts {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					r := NewReader(zeroReader{}, 0, WithPool(p))
+					buf := make([]byte, bufSize)
+					for time.Now().Before(deadline) {
+						n, err := r.Read(buf)
+						if err != nil {
+							t.Error(err)
+							return
+						}
+						counts[i] += int64(n)
+					}
+				}(i)
+			}
+			wg.Wait()
+			elapsed := time.Since(start).Seconds()
+
+			var total int64
+			for _, c := range counts {
+				total += c
+			}
+			if rate := float64(total) / elapsed; math.Abs(rate-bandwidth) > 0.1*bandwidth {
+				t.Errorf("Want aggregate rate of %d B/s, got %.0f B/s.", bandwidth, rate)
+			}
+
+			share := float64(bandwidth) / float64(n)
+			for i, c := range counts {
+				if rate := float64(c) / elapsed; math.Abs(rate-share) > 0.2*share {
+					t.Errorf("Goroutine %d: want rate of %.0f B/s, got %.0f B/s.", i, share, rate)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// scannerReader releases whole lines at a limited rate.
+type scannerReader struct {
+	src  *bufio.Reader
+	lim  *MessageLimiter
+	line []byte // unread remainder of the current line
+	err  error  // deferred error of the underlying reader
+}
+
+// NewScannerReader returns a reader that wraps reader r and releases at most
+// linesPerSecond lines per second. It buffers whole lines internally and only
+// hands out the next line once the previous one has been read completely and
+// the rate allows. A trailing line without a newline counts as a line. If
+// linesPerSecond is zero or negative, the reader will not limit.
+func NewScannerReader(r io.Reader, linesPerSecond int) io.Reader {
+	return &scannerReader{
+		src: bufio.NewReader(r),
+		lim: NewMessageLimiter(linesPerSecond),
+	}
+}
+
+// Read implements the io.Reader interface.
+func (r *scannerReader) Read(p []byte) (n int, err error) {
+	if len(r.line) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+
+		r.line, r.err = r.src.ReadBytes('\n')
+		if len(r.line) == 0 {
+			return 0, r.err
+		}
+
+		if err := r.lim.Acquire(context.Background()); err != nil {
+			return 0, err
+		}
+	}
+
+	n = copy(p, r.line)
+	r.line = r.line[n:]
+
+	return n, nil
+}
@@ -0,0 +1,65 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScannerReader(t *testing.T) {
+	t.Parallel()
+
+	const text = "one\ntwo\nthree\nfour\nfive"
+	sr := NewScannerReader(strings.NewReader(text), 100)
+
+	start := time.Now()
+	got, err := ioutil.ReadAll(sr)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if string(got) != text {
+		t.Errorf("Want %q, got %q.", text, got)
+	}
+	// The first of five lines passes immediately.
+	if dur < 40*time.Millisecond || dur > 200*time.Millisecond {
+		t.Errorf("Took %s, want 40ms.", dur)
+	}
+}
+
+func TestScannerReaderSmallBuffer(t *testing.T) {
+	t.Parallel()
+
+	const text = "a long line\nanother long line\n"
+	scanner := bufio.NewScanner(NewScannerReader(strings.NewReader(text), 0))
+	scanner.Buffer(make([]byte, 4), 64)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Error(err)
+	}
+	if len(lines) != 2 || lines[0] != "a long line" || lines[1] != "another long line" {
+		t.Errorf("Want two lines, got %q.", lines)
+	}
+}
@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// ReadSeeker wraps another io.ReadSeeker. Reads maintain a given bandwidth,
+// seeks are delegated unchanged.
+type ReadSeeker struct {
+	Reader
+	seeker io.Seeker
+}
+
+// NewReadSeeker returns a new ReadSeeker that wraps rs and maintains the
+// given bandwidth. If bandwidth is zero or negative, the ReadSeeker will not
+// limit.
+func NewReadSeeker(rs io.ReadSeeker, bandwidth int, opts ...Option) *ReadSeeker {
+	return &ReadSeeker{
+		Reader: Reader{
+			src: rs,
+			lim: newLimiter(float64(bandwidth), opts),
+		},
+		seeker: rs,
+	}
+}
+
+// Seek implements the io.Seeker interface. A successful seek resets the
+// limiter, so that credits accumulated at the old position do not cause a
+// burst at the new one.
+func (s *ReadSeeker) Seek(offset int64, whence int) (int64, error) {
+	pos, err := s.seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	s.lim.reset()
+
+	return pos, nil
+}
+
+// Rewrap replaces the wrapped io.ReadSeeker with rs for both reads and
+// seeks, but keeps the state of the limiter, see Reader.Rewrap. Rewrap must
+// not be called concurrently with Read or Seek.
+func (s *ReadSeeker) Rewrap(rs io.ReadSeeker) {
+	s.Reader.Rewrap(rs)
+	s.seeker = rs
+}
+
+// WriteSeeker wraps another io.WriteSeeker. Writes maintain a given
+// bandwidth, seeks are delegated unchanged.
+type WriteSeeker struct {
+	Writer
+	seeker io.Seeker
+}
+
+// NewWriteSeeker returns a new WriteSeeker that wraps ws and maintains the
+// given bandwidth. If bandwidth is zero or negative, the WriteSeeker will not
+// limit.
+func NewWriteSeeker(ws io.WriteSeeker, bandwidth int, opts ...Option) *WriteSeeker {
+	return &WriteSeeker{
+		Writer: Writer{
+			dst: ws,
+			lim: newLimiter(float64(bandwidth), opts),
+		},
+		seeker: ws,
+	}
+}
+
+// Seek implements the io.Seeker interface. A successful backward seek resets
+// the limiter, because rewriting earlier data is a logical discontinuity of
+// the stream and credits accumulated before should not carry over. A forward
+// seek carries the bucket over.
+func (s *WriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	cur, err := s.seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return cur, err
+	}
+
+	pos, err := s.seeker.Seek(offset, whence)
+	if err != nil {
+		return pos, err
+	}
+
+	if pos < cur {
+		s.lim.reset()
+	}
+
+	return pos, nil
+}
+
+// Rewrap replaces the wrapped io.WriteSeeker with ws for both writes and
+// seeks, but keeps the state of the limiter, see Writer.Rewrap. Rewrap must
+// not be called concurrently with Write or Seek.
+func (s *WriteSeeker) Rewrap(ws io.WriteSeeker) {
+	s.Writer.Rewrap(ws)
+	s.seeker = ws
+}
@@ -0,0 +1,193 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestReadSeeker(t *testing.T) {
+	t.Parallel()
+
+	data := make([]byte, 1000)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	rs := NewReadSeeker(bytes.NewReader(data), 1<<20)
+
+	p := make([]byte, 100)
+	if _, err := io.ReadFull(rs, p); err != nil {
+		t.Fatal(err)
+	}
+	// Pretend there are accumulated credits.
+	rs.lim.bucket = 100
+
+	pos, err := rs.Seek(500, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 500 {
+		t.Errorf("Want position 500, got %d.", pos)
+	}
+	if rs.lim.bucket != 0 {
+		t.Errorf("Want bucket to be reset, got %d.", rs.lim.bucket)
+	}
+
+	if _, err := io.ReadFull(rs, p[:1]); err != nil {
+		t.Fatal(err)
+	}
+	if p[0] != data[500] {
+		t.Errorf("Want byte %d after seek, got %d.", data[500], p[0])
+	}
+
+	if _, err := rs.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Want error on negative position.")
+	}
+}
+
+// memWriteSeeker is an in-memory io.WriteSeeker.
+type memWriteSeeker struct {
+	buf []byte
+	pos int64
+}
+
+func (ws *memWriteSeeker) Write(p []byte) (int, error) {
+	if end := ws.pos + int64(len(p)); end > int64(len(ws.buf)) {
+		ws.buf = append(ws.buf, make([]byte, end-int64(len(ws.buf)))...)
+	}
+	n := copy(ws.buf[ws.pos:], p)
+	ws.pos += int64(n)
+	return n, nil
+}
+
+func (ws *memWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += ws.pos
+	case io.SeekEnd:
+		offset += int64(len(ws.buf))
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	ws.pos = offset
+	return offset, nil
+}
+
+func TestWriteSeeker(t *testing.T) {
+	t.Parallel()
+
+	mws := new(memWriteSeeker)
+	ws := NewWriteSeeker(mws, 1<<20)
+
+	if _, err := ws.Write([]byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	// Pretend there are accumulated credits.
+	ws.lim.bucket = 100
+
+	pos, err := ws.Seek(6, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 6 {
+		t.Errorf("Want position 6, got %d.", pos)
+	}
+	if ws.lim.bucket != 0 {
+		t.Errorf("Want bucket to be reset on backward seek, got %d.", ws.lim.bucket)
+	}
+
+	if _, err := ws.Write([]byte("gophe")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mws.buf); got != "hello gophe" {
+		t.Errorf("Want %q, got %q.", "hello gophe", got)
+	}
+
+	if _, err := ws.Seek(-1, io.SeekStart); err == nil {
+		t.Error("Want error on negative position.")
+	}
+}
+
+func TestWriteSeekerForward(t *testing.T) {
+	t.Parallel()
+
+	mws := new(memWriteSeeker)
+	ws := NewWriteSeeker(mws, 1<<20)
+
+	if _, err := ws.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	// Pretend there are accumulated credits.
+	ws.lim.bucket = 100
+
+	pos, err := ws.Seek(6, io.SeekStart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pos != 6 {
+		t.Errorf("Want position 6, got %d.", pos)
+	}
+	if ws.lim.bucket != 100 {
+		t.Errorf("Want bucket to carry over on forward seek, got %d.", ws.lim.bucket)
+	}
+}
+
+func TestReadSeekerRewrap(t *testing.T) {
+	t.Parallel()
+
+	rs := NewReadSeeker(bytes.NewReader([]byte("old data")), 0)
+	rs.Rewrap(bytes.NewReader([]byte("new data")))
+
+	if _, err := rs.Seek(4, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	p := make([]byte, 4)
+	if _, err := io.ReadFull(rs, p); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(p); got != "data" {
+		t.Errorf("Want %q, got %q.", "data", got)
+	}
+}
+
+func TestWriteSeekerRewrap(t *testing.T) {
+	t.Parallel()
+
+	old, mws := new(memWriteSeeker), new(memWriteSeeker)
+	ws := NewWriteSeeker(old, 0)
+	ws.Rewrap(mws)
+
+	if _, err := ws.Write([]byte("hello world")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Seek(6, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Write([]byte("gophe")); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(mws.buf); got != "hello gophe" {
+		t.Errorf("Want %q, got %q.", "hello gophe", got)
+	}
+	if old.pos != 0 || len(old.buf) != 0 {
+		t.Errorf("Want the old WriteSeeker untouched, got %q at %d.", old.buf, old.pos)
+	}
+}
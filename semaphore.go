@@ -0,0 +1,67 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+// Semaphore limits the number of concurrent Read and Write calls across all
+// readers and writers that share it with WithSemaphore. Together with the
+// bandwidth it enforces both a rate limit and a concurrency limit, like
+// servers that limit the per-connection bandwidth as well as the number of
+// simultaneous connections. A Semaphore is safe for concurrent use.
+type Semaphore struct {
+	slots chan struct{}
+}
+
+// NewSemaphore returns a new Semaphore that admits at most n concurrent
+// operations. If n is zero or negative, the Semaphore admits any number of
+// operations.
+func NewSemaphore(n int) *Semaphore {
+	s := new(Semaphore)
+	if n > 0 {
+		s.slots = make(chan struct{}, n)
+	}
+	return s
+}
+
+// WithSemaphore makes each Read and Write call hold a slot of s for its
+// whole duration, including the penalty sleep. A call blocks until a slot is
+// free or the context of the limiter is done.
+func WithSemaphore(s *Semaphore) Option {
+	return func(l *limiter) { l.sem = s }
+}
+
+// acquire blocks until the semaphore of the limiter, if any, has a free
+// slot. It fails if the context of the limiter is done before that.
+func (l *limiter) acquire() error {
+	if l.sem == nil || l.sem.slots == nil {
+		return nil
+	}
+
+	select {
+	case l.sem.slots <- struct{}{}:
+		return nil
+	case <-l.ctx.Done():
+		return l.ctx.Err()
+	}
+}
+
+// release frees the slot taken by acquire.
+func (l *limiter) release() {
+	if l.sem == nil || l.sem.slots == nil {
+		return
+	}
+	<-l.sem.slots
+}
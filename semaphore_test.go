@@ -0,0 +1,84 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// concurrencyReader records the maximum number of concurrent Read calls.
+type concurrencyReader struct {
+	current, peak int32
+}
+
+func (r *concurrencyReader) Read(p []byte) (int, error) {
+	cur := atomic.AddInt32(&r.current, 1)
+	defer atomic.AddInt32(&r.current, -1)
+	for {
+		peak := atomic.LoadInt32(&r.peak)
+		if cur <= peak || atomic.CompareAndSwapInt32(&r.peak, peak, cur) {
+			break
+		}
+	}
+	time.Sleep(time.Millisecond)
+	return len(p), nil
+}
+
+func TestSemaphore(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(2)
+	src := new(concurrencyReader)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := NewReader(src, 100000, WithSemaphore(sem))
+			if _, err := io.CopyN(ioutil.Discard, r, 5000); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak := atomic.LoadInt32(&src.peak); peak > 2 {
+		t.Errorf("Want at most 2 concurrent reads, got %d.", peak)
+	}
+}
+
+func TestSemaphoreContext(t *testing.T) {
+	t.Parallel()
+
+	sem := NewSemaphore(1)
+	sem.slots <- struct{}{}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	w := NewWriter(ioutil.Discard, 0, WithSemaphore(sem), WithContext(ctx))
+	if _, err := w.Write([]byte("x")); err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+}
@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+)
+
+// ErrConcurrentRead is returned by SequentialReader.Read, if another Read is
+// still in progress.
+var ErrConcurrentRead = errors.New("bwio: concurrent read")
+
+// SequentialReader wraps another reader, maintains a given bandwidth and
+// enforces sequential access. Concurrent calls to Read fail immediately with
+// ErrConcurrentRead instead of blocking, which suits protocols that do not
+// expect concurrent reads.
+type SequentialReader struct {
+	busy int32 // accessed atomically; 1 while a Read is in progress
+	r    *Reader
+}
+
+// NewSequentialReader returns a new SequentialReader that wraps r and
+// maintains the given bandwidth. If bandwidth is zero or negative, the
+// SequentialReader will not limit.
+func NewSequentialReader(r io.Reader, bandwidth int, opts ...Option) *SequentialReader {
+	return &SequentialReader{r: NewReader(r, bandwidth, opts...)}
+}
+
+// Read implements the io.Reader interface and maintains the given bandwidth.
+// It returns ErrConcurrentRead, if another Read is still in progress,
+// including its penalty sleep.
+func (s *SequentialReader) Read(p []byte) (int, error) {
+	// sync.Mutex lacks TryLock before Go 1.18, so a flag serves instead.
+	if !atomic.CompareAndSwapInt32(&s.busy, 0, 1) {
+		return 0, ErrConcurrentRead
+	}
+	defer atomic.StoreInt32(&s.busy, 0)
+
+	return s.r.Read(p)
+}
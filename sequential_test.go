@@ -0,0 +1,47 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestSequentialReader(t *testing.T) {
+	t.Parallel()
+
+	// Reading 100 bytes at 1000 B/s sleeps for 100ms.
+	sr := NewSequentialReader(bytes.NewReader(make([]byte, 200)), 1000)
+	done := make(chan error)
+	go func() {
+		_, err := sr.Read(make([]byte, 100))
+		done <- err
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	if _, err := sr.Read(make([]byte, 100)); err != ErrConcurrentRead {
+		t.Errorf("Want %v, got %v.", ErrConcurrentRead, err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	if n, err := sr.Read(make([]byte, 100)); err != nil || n != 100 {
+		t.Errorf("Want 100 bytes, got %d, %v.", n, err)
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+// sleeper pauses the limiter. Sleep returns early with ctx.Err() if ctx is
+// done before d has passed.
+type sleeper interface {
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// defaultSleeper sleeps on a timer.
+type defaultSleeper struct{}
+
+func (defaultSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// sleepFunc adapts a plain sleep function like time.Sleep to a sleeper, so
+// that tests can replace the sleeping of a limiter with a recording function.
+// The limiter assumes that its clock advances by each penalty slept, so a
+// function that does not sleep must advance the clock of the limiter itself,
+// e.g. with a fakeClock; otherwise the penalties grow with every operation:
+//
+//	clock := &fakeClock{t: time.Unix(0, 0)}
+//	r.lim.now = clock.now
+//	r.lim.sleeper = sleepFunc(func(d time.Duration) { clock.t = clock.t.Add(d) })
+//
+// The function cannot be interrupted, so ctx is only checked before and
+// after it.
+type sleepFunc func(d time.Duration)
+
+func (f sleepFunc) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	f(d)
+	return ctx.Err()
+}
+
+// channelSleeper sleeps on a timer like defaultSleeper, but also wakes up as
+// soon as the channel is closed. Closing it ends all current and future
+// sleeps immediately, which lets tests skip the penalties.
+type channelSleeper chan struct{}
+
+func (c channelSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// defaultTickInterval is the tick interval of WithTicker.
+const defaultTickInterval = 5 * time.Millisecond
+
+// tickerSleeper sleeps by blocking on a ticker instead of a timer per sleep,
+// so wake-ups happen on tick boundaries. Penalties are rounded up to the
+// next tick; the limiter credits the excess to the following operations,
+// which then pass without sleeping. The ticker starts with the first sleep
+// and stops once the tickerSleeper is garbage collected.
+type tickerSleeper struct {
+	interval time.Duration
+	ticker   *time.Ticker
+}
+
+func newTickerSleeper(interval time.Duration) *tickerSleeper {
+	s := &tickerSleeper{interval: interval}
+	runtime.SetFinalizer(s, (*tickerSleeper).stop)
+	return s
+}
+
+func (s *tickerSleeper) Sleep(ctx context.Context, d time.Duration) error {
+	if s.ticker == nil {
+		s.ticker = time.NewTicker(s.interval)
+	}
+
+	deadline := time.Now().Add(d)
+	for {
+		select {
+		case <-s.ticker.C:
+			if !time.Now().Before(deadline) {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *tickerSleeper) stop() {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+}
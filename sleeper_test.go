@@ -0,0 +1,163 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestDefaultSleeper(t *testing.T) {
+	t.Parallel()
+
+	start := time.Now()
+	if err := (defaultSleeper{}).Sleep(context.Background(), 20*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	if dur := time.Since(start); dur < 20*time.Millisecond {
+		t.Errorf("Took %s, want 20ms.", dur)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := (defaultSleeper{}).Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("Want %v, got %v.", context.Canceled, err)
+	}
+}
+
+func TestChannelSleeper(t *testing.T) {
+	t.Parallel()
+
+	c := make(channelSleeper)
+	close(c)
+
+	start := time.Now()
+	if err := c.Sleep(context.Background(), time.Hour); err != nil {
+		t.Error(err)
+	}
+	if dur := time.Since(start); dur > 100*time.Millisecond {
+		t.Errorf("Took %s, want no sleep.", dur)
+	}
+
+	// A transfer that would take 100s completes immediately.
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 10)
+	r.lim.sleeper = c
+	n, err := io.Copy(ioutil.Discard, r)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 1000 {
+		t.Errorf("Want 1000 bytes, got %d.", n)
+	}
+}
+
+func TestSleepFunc(t *testing.T) {
+	t.Parallel()
+
+	// Reading 1000 bytes at 100 B/s in chunks of 100 bytes sleeps for 1s
+	// per chunk, which the recording function skips. It advances the fake
+	// clock instead, as the limiter expects.
+	var sleeps []time.Duration
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 100)
+	r.lim.now = clock.now
+	r.lim.sleeper = sleepFunc(func(d time.Duration) {
+		sleeps = append(sleeps, d)
+		clock.t = clock.t.Add(d)
+	})
+
+	start := time.Now()
+	if _, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 100)); err != nil {
+		t.Error(err)
+	}
+	if dur := time.Since(start); dur > 100*time.Millisecond {
+		t.Errorf("Took %s, want no sleep.", dur)
+	}
+	if len(sleeps) != 10 {
+		t.Fatalf("Want 10 sleeps, got %v.", sleeps)
+	}
+	for i, d := range sleeps {
+		if d != time.Second {
+			t.Errorf("Sleep %d: want 1s, got %s.", i, d)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sleepFunc(time.Sleep).Sleep(ctx, time.Hour); err != context.Canceled {
+		t.Errorf("Want %v, got %v.", context.Canceled, err)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// Reading 1000 bytes at 100 B/s would take 10s.
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 100, WithContext(ctx))
+
+	start := time.Now()
+	_, err := io.Copy(ioutil.Discard, r)
+	dur := time.Since(start)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Want %v, got %v.", context.DeadlineExceeded, err)
+	}
+	if dur > time.Second {
+		t.Errorf("Took %s, want 50ms.", dur)
+	}
+
+	if _, err := r.Read(make([]byte, 1)); err != context.DeadlineExceeded {
+		t.Errorf("Want %v on subsequent read, got %v.", context.DeadlineExceeded, err)
+	}
+}
+
+func TestTickerSleeper(t *testing.T) {
+	t.Parallel()
+
+	s := newTickerSleeper(10 * time.Millisecond)
+	defer s.stop()
+
+	start := time.Now()
+	if err := s.Sleep(context.Background(), 15*time.Millisecond); err != nil {
+		t.Error(err)
+	}
+	// The sleep is rounded up to the second tick.
+	if dur := time.Since(start); dur < 15*time.Millisecond || dur > 100*time.Millisecond {
+		t.Errorf("Took %s, want 20ms.", dur)
+	}
+
+	// Reading 1000 bytes at 10000 B/s takes 100ms, ticks or not.
+	r := NewReader(&chunkReader{r: bytes.NewReader(make([]byte, 1000)), size: 10}, 10000, WithTicker())
+	start = time.Now()
+	n, err := io.Copy(ioutil.Discard, r)
+	dur := time.Since(start)
+	if err != nil {
+		t.Error(err)
+	}
+	if n != 1000 {
+		t.Errorf("Want 1000 bytes, got %d.", n)
+	}
+	if dur < 80*time.Millisecond || dur > 300*time.Millisecond {
+		t.Errorf("Took %s, want 100ms.", dur)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !bwio_fast_stall
+// +build !bwio_fast_stall
+
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "time"
+
+// stalled reports whether the bucket is older than the stall threshold. It
+// compares the age the penalty calculation has computed anyway. Build with
+// the tag bwio_fast_stall for a variant comparing points in time instead.
+func (l *limiter) stalled(_ time.Time, bucketAge, threshold time.Duration) bool {
+	return bucketAge > threshold
+}
@@ -0,0 +1,32 @@
+//go:build bwio_fast_stall
+// +build bwio_fast_stall
+
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "time"
+
+// stalled reports whether the bucket is older than the stall threshold. This
+// variant, selected by the build tag bwio_fast_stall, compares now with the
+// deadline start+threshold instead of the bucket age, which may be cheaper
+// on platforms where time comparison is cheap. The deadline cannot be cached
+// at reset time, because the threshold depends on the buffer size of each
+// operation.
+func (l *limiter) stalled(now time.Time, _, threshold time.Duration) bool {
+	return now.After(l.start.Add(threshold))
+}
@@ -0,0 +1,168 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats summarizes the transfer of a Reader or Writer so far.
+type Stats struct {
+	// BytesTransferred is the number of bytes read or written.
+	BytesTransferred int64
+	// Duration is the time since the first operation.
+	Duration time.Duration
+	// AchievedBandwidth is BytesTransferred per Duration in bytes per
+	// second.
+	AchievedBandwidth float64
+	// Sleep is the total time actually slept for penalties.
+	Sleep time.Duration
+	// StallCount is the number of stalls the limiter has detected.
+	StallCount int
+}
+
+// MarshalJSON implements the json.Marshaler interface. Durations are given in
+// milliseconds, e.g.
+//
+//	{"bytesTransferred":1024,"durationMs":1000,"achievedBandwidthBps":1024,"sleepMs":900,"stallCount":0}
+func (s Stats) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		BytesTransferred     int64   `json:"bytesTransferred"`
+		DurationMs           int64   `json:"durationMs"`
+		AchievedBandwidthBps float64 `json:"achievedBandwidthBps"`
+		SleepMs              int64   `json:"sleepMs"`
+		StallCount           int     `json:"stallCount"`
+	}{
+		BytesTransferred:     s.BytesTransferred,
+		DurationMs:           s.Duration.Milliseconds(),
+		AchievedBandwidthBps: s.AchievedBandwidth,
+		SleepMs:              s.Sleep.Milliseconds(),
+		StallCount:           s.StallCount,
+	})
+}
+
+// stats returns the stats of the limiter. It is safe to call concurrently
+// with operations.
+func (l *limiter) stats() Stats {
+	s := Stats{
+		BytesTransferred: atomic.LoadInt64(&l.transferred),
+		Sleep:            time.Duration(atomic.LoadInt64(&l.sleptActual)),
+		StallCount:       int(atomic.LoadInt64(&l.stalls)),
+	}
+	if first := atomic.LoadInt64(&l.firstUse); first != 0 {
+		s.Duration = time.Since(time.Unix(0, first))
+	}
+	if s.Duration > 0 {
+		s.AchievedBandwidth = float64(s.BytesTransferred) / s.Duration.Seconds()
+	}
+	return s
+}
+
+// Stats returns the stats of the Reader. It is safe to call Stats
+// concurrently with Read.
+func (r *Reader) Stats() Stats {
+	return r.lim.stats()
+}
+
+// Stats returns the stats of the Writer. It is safe to call Stats
+// concurrently with Write.
+func (w *Writer) Stats() Stats {
+	return w.lim.stats()
+}
+
+// describe returns a summary of the configured bandwidth and the stats of the
+// limiter for debugging, prefixed with the given type name.
+func (l *limiter) describe(typeName string) string {
+	s := l.stats()
+	return fmt.Sprintf("%s{bandwidth: %d, rate: %.0f B/s, total: %d B}",
+		typeName, l.bandwidthInt(), s.AchievedBandwidth, s.BytesTransferred)
+}
+
+// String implements the fmt.Stringer interface. It summarizes the bandwidth
+// and the stats of the Reader, e.g.
+//
+//	bwio.Reader{bandwidth: 524288, rate: 519200 B/s, total: 10485760 B}
+func (r *Reader) String() string {
+	return r.lim.describe("bwio.Reader")
+}
+
+// String implements the fmt.Stringer interface. It summarizes the bandwidth
+// and the stats of the Writer, e.g.
+//
+//	bwio.Writer{bandwidth: 524288, rate: 519200 B/s, total: 10485760 B}
+func (w *Writer) String() string {
+	return w.lim.describe("bwio.Writer")
+}
+
+// peakMeter tracks the highest number of bytes transferred within a window
+// of one second. A window starts with the first operation after the previous
+// one has ended.
+type peakMeter struct {
+	mu    sync.Mutex
+	start time.Time // of the current window
+	bytes int64     // transferred in the current window
+	peak  int64     // of all ended windows
+}
+
+func (m *peakMeter) add(now time.Time, n int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.start.IsZero() || now.Sub(m.start) >= time.Second {
+		if m.bytes > m.peak {
+			m.peak = m.bytes
+		}
+		m.start, m.bytes = now, 0
+	}
+	m.bytes += int64(n)
+}
+
+// get returns the peak in bytes per second, including the current window.
+func (m *peakMeter) get() float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.bytes > m.peak {
+		return float64(m.bytes)
+	}
+	return float64(m.peak)
+}
+
+func (m *peakMeter) reset() {
+	m.mu.Lock()
+	m.start, m.bytes, m.peak = time.Time{}, 0, 0
+	m.mu.Unlock()
+}
+
+// PeakBandwidth returns the highest rate in bytes per second that the Reader
+// achieved within a single second, e.g. the unthrottled first read, which the
+// average AchievedBandwidth of Stats masks. It is safe to call PeakBandwidth
+// concurrently with Read.
+func (r *Reader) PeakBandwidth() float64 {
+	return r.lim.peak.get()
+}
+
+// PeakBandwidth returns the highest rate in bytes per second that the Writer
+// achieved within a single second, see Reader.PeakBandwidth. It is safe to
+// call PeakBandwidth concurrently with Write.
+func (w *Writer) PeakBandwidth() float64 {
+	return w.lim.peak.get()
+}
@@ -0,0 +1,129 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	t.Parallel()
+
+	r := NewReader(bytes.NewReader(make([]byte, 200)), 1000)
+	if s := r.Stats(); s != (Stats{}) {
+		t.Errorf("Want zero stats before the first read, got %+v.", s)
+	}
+
+	// Reading 200 bytes at 1000 B/s takes 200ms.
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+
+	s := r.Stats()
+	if s.BytesTransferred != 200 {
+		t.Errorf("Want 200 bytes, got %d.", s.BytesTransferred)
+	}
+	if s.Duration < 150*time.Millisecond || s.Duration > 400*time.Millisecond {
+		t.Errorf("Took %s, want 200ms.", s.Duration)
+	}
+	if s.AchievedBandwidth <= 0 || s.AchievedBandwidth > 1100 {
+		t.Errorf("Want bandwidth up to 1000 B/s, got %f.", s.AchievedBandwidth)
+	}
+	if s.Sleep <= 0 {
+		t.Errorf("Want penalty sleeps, got %s.", s.Sleep)
+	}
+}
+
+func TestStatsMarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	s := Stats{
+		BytesTransferred:  2048,
+		Duration:          2 * time.Second,
+		AchievedBandwidth: 1024,
+		Sleep:             1500 * time.Millisecond,
+		StallCount:        1,
+	}
+
+	got, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"bytesTransferred":2048,"durationMs":2000,"achievedBandwidthBps":1024,"sleepMs":1500,"stallCount":1}`
+	if string(got) != want {
+		t.Errorf("Want %s, got %s.", want, got)
+	}
+}
+
+func TestString(t *testing.T) {
+	t.Parallel()
+
+	var _ fmt.Stringer = (*Reader)(nil)
+	var _ fmt.Stringer = (*Writer)(nil)
+
+	w := NewWriter(ioutil.Discard, 512*KBps)
+	if got, want := fmt.Sprint(w), "bwio.Writer{bandwidth: 524288, rate: 0 B/s, total: 0 B}"; got != want {
+		t.Errorf("Want %q, got %q.", want, got)
+	}
+
+	r := NewReader(bytes.NewReader(make([]byte, 1000)), 0)
+	if _, err := io.Copy(ioutil.Discard, r); err != nil {
+		t.Fatal(err)
+	}
+	got := fmt.Sprintf("%v", r)
+	if !strings.HasPrefix(got, "bwio.Reader{bandwidth: 0, rate: ") || !strings.HasSuffix(got, " B/s, total: 1000 B}") {
+		t.Errorf("Want summary of 1000 bytes read, got %q.", got)
+	}
+}
+
+func TestPeakBandwidth(t *testing.T) {
+	t.Parallel()
+
+	clock := &fakeClock{t: time.Unix(0, 0)}
+	r := NewReader(bytes.NewReader(make([]byte, 10000)), 1000)
+	r.lim.now = clock.now
+	r.lim.sleeper = clock
+
+	if got := r.PeakBandwidth(); got != 0 {
+		t.Errorf("Want no peak before the first read, got %f.", got)
+	}
+
+	// The first read of 5000 bytes is not throttled, the following reads
+	// of 100 bytes keep 1000 B/s.
+	if _, err := r.Read(make([]byte, 5000)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.CopyBuffer(struct{ io.Writer }{ioutil.Discard}, struct{ io.Reader }{r}, make([]byte, 100)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := r.PeakBandwidth(); got < 5000 || got > 6000 {
+		t.Errorf("Want peak of about 5000 B/s, got %f.", got)
+	}
+
+	r.Reset()
+	if got := r.PeakBandwidth(); got != 0 {
+		t.Errorf("Want no peak after reset, got %f.", got)
+	}
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package testutil provides helpers to simulate network conditions in tests.
+package testutil
+
+import (
+	"io"
+	"time"
+)
+
+type latencyReader struct {
+	r       io.Reader
+	latency time.Duration
+}
+
+// WrapWithLatency returns a reader that sleeps for latency before each Read
+// from r. Wrap the result with bwio.NewReader to simulate both latency and
+// bandwidth.
+func WrapWithLatency(r io.Reader, latency time.Duration) io.Reader {
+	return &latencyReader{r: r, latency: latency}
+}
+
+func (lr *latencyReader) Read(p []byte) (int, error) {
+	if lr.latency > 0 {
+		time.Sleep(lr.latency)
+	}
+	return lr.r.Read(p)
+}
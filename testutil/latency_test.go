@@ -0,0 +1,56 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package testutil_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/jwkohnen/bwio"
+	"github.com/jwkohnen/bwio/testutil"
+)
+
+func TestWrapWithLatency(t *testing.T) {
+	t.Parallel()
+
+	r := testutil.WrapWithLatency(bytes.NewReader(make([]byte, 300)), 50*time.Millisecond)
+	p := make([]byte, 100)
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := io.ReadFull(r, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if took := time.Since(start); took < 150*time.Millisecond || took > 250*time.Millisecond {
+		t.Errorf("Took %s, want 150ms.", took)
+	}
+}
+
+func ExampleWrapWithLatency() {
+	// 10 KiB at 50 KBps with 20ms latency per read.
+	src := testutil.WrapWithLatency(bytes.NewReader(make([]byte, 10<<10)), 20*time.Millisecond)
+	r := bwio.NewReader(src, 50*bwio.KBps)
+
+	n, err := io.Copy(ioutil.Discard, r)
+	fmt.Println(n, err)
+	// Output: 10240 <nil>
+}
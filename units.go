@@ -0,0 +1,52 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "io"
+
+// Bandwidth units in bytes per second, e.g. NewReader(r, 500*KBps). The
+// units are binary, so KBps is 1024 bytes per second.
+const (
+	Bps  = 1
+	KBps = 1 << 10 * Bps
+	MBps = 1 << 10 * KBps
+	GBps = 1 << 10 * MBps
+)
+
+// BitsPerSecond converts the networking convention of bits per second to the
+// bytes per second this package uses, e.g. NewWriter(w, BitsPerSecond(1e6))
+// for 1 Mbit/s. The result is rounded up, so that a positive rate never
+// turns into zero, i.e. unlimited. Zero or negative rates yield zero.
+func BitsPerSecond(bps int) int {
+	if bps <= 0 {
+		return 0
+	}
+	return (bps + 7) / 8
+}
+
+// NewReaderBps returns a new reader like NewReader, except that the bandwidth
+// is given in bits per second, the convention of networking tools like iperf
+// or tc. It is rounded up to whole bytes per second.
+func NewReaderBps(r io.Reader, bitsPerSecond int, opts ...Option) *Reader {
+	return NewReader(r, BitsPerSecond(bitsPerSecond), opts...)
+}
+
+// NewWriterBps returns a new writer like NewWriter, except that the bandwidth
+// is given in bits per second. It is rounded up to whole bytes per second.
+func NewWriterBps(w io.Writer, bitsPerSecond int, opts ...Option) *Writer {
+	return NewWriter(w, BitsPerSecond(bitsPerSecond), opts...)
+}
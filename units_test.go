@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import "testing"
+
+func TestUnits(t *testing.T) {
+	t.Parallel()
+
+	if 500*KBps != 500<<10 {
+		t.Errorf("Want 500 KBps to be %d, got %d.", 500<<10, 500*KBps)
+	}
+	if MBps != 1<<20 {
+		t.Errorf("Want 1 MBps to be %d, got %d.", 1<<20, MBps)
+	}
+	if GBps != 1<<30 {
+		t.Errorf("Want 1 GBps to be %d, got %d.", 1<<30, GBps)
+	}
+}
+
+func TestBitsPerSecond(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		bps  int
+		want int
+	}{
+		{-8, 0},
+		{0, 0},
+		{1, 1},
+		{8, 1},
+		{9, 2},
+		{56000, 7000},
+		{1e9, 125e6},
+	}
+	for _, testc := range testt {
+		if got := BitsPerSecond(testc.bps); got != testc.want {
+			t.Errorf("BitsPerSecond(%d): want %d, got %d.", testc.bps, testc.want, got)
+		}
+	}
+}
+
+func TestNewReaderBps(t *testing.T) {
+	t.Parallel()
+
+	r := NewReaderBps(nil, 8*KBps)
+	if got := r.Bandwidth(); got != KBps {
+		t.Errorf("Want bandwidth %d, got %d.", KBps, got)
+	}
+	w := NewWriterBps(nil, 56000)
+	if got := w.Bandwidth(); got != 7000 {
+		t.Errorf("Want bandwidth %d, got %d.", 7000, got)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package websocket limits the bandwidth of gorilla/websocket connections.
+// The reader and writer of gorilla/websocket exist per message; this package
+// keeps one limiter per direction, such that all messages of a connection
+// share the bandwidth.
+package websocket
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+
+	"github.com/gorilla/websocket"
+	"github.com/jwkohnen/bwio"
+)
+
+// Conn wraps a *websocket.Conn and maintains independent bandwidths for
+// reading and writing across all messages. NextReader, NextWriter,
+// ReadMessage and WriteMessage are limited; all other methods are those of
+// the wrapped connection.
+type Conn struct {
+	*websocket.Conn
+	r *bwio.Reader
+	w *bwio.Writer
+}
+
+// NewConn returns a new Conn that wraps c and maintains readBandwidth for
+// incoming and writeBandwidth for outgoing messages. The options apply to
+// both directions. If a bandwidth is zero or negative, the Conn will not
+// limit that direction.
+func NewConn(c *websocket.Conn, readBandwidth, writeBandwidth int, opts ...bwio.Option) *Conn {
+	return &Conn{
+		Conn: c,
+		r:    bwio.NewReader(nil, readBandwidth, opts...),
+		w:    bwio.NewWriter(nil, writeBandwidth, opts...),
+	}
+}
+
+// NextReader returns the next data message like websocket.Conn.NextReader,
+// except that reading from it maintains the read bandwidth. The bandwidth is
+// shared with all previous and following messages. Errors are those of
+// gorilla/websocket, so websocket.IsCloseError and
+// websocket.IsUnexpectedCloseError work as usual.
+func (c *Conn) NextReader() (messageType int, r io.Reader, err error) {
+	messageType, r, err = c.Conn.NextReader()
+	if err != nil {
+		return messageType, r, err
+	}
+	c.r.Rewrap(r)
+	return messageType, messageReader{c.r}, nil
+}
+
+// messageReader reads through the limited Reader and returns the original
+// errors instead of a *bwio.BwioError, because gorilla/websocket inspects
+// close errors with a plain type assertion.
+type messageReader struct {
+	r *bwio.Reader
+}
+
+func (r messageReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	var bwErr *bwio.BwioError
+	if errors.As(err, &bwErr) {
+		err = bwErr.Cause
+	}
+	return n, err
+}
+
+// NextWriter returns a writer for the next message like
+// websocket.Conn.NextWriter, except that writing to it maintains the write
+// bandwidth. The bandwidth is shared with all previous and following
+// messages.
+func (c *Conn) NextWriter(messageType int) (io.WriteCloser, error) {
+	w, err := c.Conn.NextWriter(messageType)
+	if err != nil {
+		return nil, err
+	}
+	c.w.Rewrap(w)
+	return messageWriter{Writer: c.w, c: w}, nil
+}
+
+// messageWriter writes through the limited Writer and closes the message.
+type messageWriter struct {
+	*bwio.Writer
+	c io.Closer
+}
+
+func (w messageWriter) Close() error {
+	return w.c.Close()
+}
+
+// ReadMessage is a helper method like websocket.Conn.ReadMessage, that reads
+// the next message with NextReader.
+func (c *Conn) ReadMessage() (messageType int, p []byte, err error) {
+	messageType, r, err := c.NextReader()
+	if err != nil {
+		return messageType, nil, err
+	}
+	p, err = ioutil.ReadAll(r)
+	return messageType, p, err
+}
+
+// WriteMessage is a helper method like websocket.Conn.WriteMessage, that
+// writes the message with NextWriter.
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	w, err := c.NextWriter(messageType)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
@@ -0,0 +1,133 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package websocket
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// echoServer returns a server that echoes all messages.
+func echoServer(t *testing.T) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer c.Close()
+		for {
+			mt, p, err := c.ReadMessage()
+			if err != nil {
+				return
+			}
+			if err := c.WriteMessage(mt, p); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func dial(t *testing.T, srv *httptest.Server) *websocket.Conn {
+	c, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestConn(t *testing.T) {
+	t.Parallel()
+
+	testt := []struct {
+		name    string
+		readBW  int
+		writeBW int
+	}{
+		{"read", 1000, 0},
+		{"write", 0, 1000},
+	}
+
+	for _, testc := range testt {
+		testc := testc
+		t.Run(testc.name, func(t *testing.T) {
+			t.Parallel()
+
+			srv := echoServer(t)
+			defer srv.Close()
+			c := NewConn(dial(t, srv), testc.readBW, testc.writeBW)
+			defer c.Close()
+
+			// Three messages of 100 bytes share 1000 B/s, so the
+			// limit carries over between messages: 300ms.
+			msg := bytes.Repeat([]byte("x"), 100)
+			start := time.Now()
+			for i := 0; i < 3; i++ {
+				if err := c.WriteMessage(websocket.BinaryMessage, msg); err != nil {
+					t.Fatal(err)
+				}
+				mt, p, err := c.ReadMessage()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if mt != websocket.BinaryMessage || !bytes.Equal(p, msg) {
+					t.Errorf("Want binary message %q, got %d %q.", msg, mt, p)
+				}
+			}
+			if dur := time.Since(start); dur < 250*time.Millisecond || dur > 500*time.Millisecond {
+				t.Errorf("Took %s, want 300ms.", dur)
+			}
+		})
+	}
+}
+
+func TestConnUnexpectedClose(t *testing.T) {
+	t.Parallel()
+
+	// The server announces a binary message of 100 bytes, but drops the
+	// connection after 10.
+	upgrader := websocket.Upgrader{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		nc := c.UnderlyingConn()
+		defer nc.Close()
+		frame := append([]byte{0x82, 100}, bytes.Repeat([]byte("x"), 10)...)
+		if _, err := nc.Write(frame); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewConn(dial(t, srv), 1000, 0)
+	defer c.Close()
+
+	_, _, err := c.ReadMessage()
+	if !websocket.IsUnexpectedCloseError(err) {
+		t.Errorf("Want an unexpected close error, got %v.", err)
+	}
+}
@@ -0,0 +1,8 @@
+module github.com/jwkohnen/bwio/websocket
+
+go 1.26.0
+
+require (
+	github.com/gorilla/websocket v1.5.3
+	github.com/jwkohnen/bwio v0.1.0
+)
@@ -0,0 +1,128 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// WindowedStats wraps an io.ReadWriter, maintains the given bandwidth in both
+// directions and records the read and write rates of the last seconds.
+type WindowedStats struct {
+	r     *Reader
+	w     *Writer
+	read  rateWindow
+	write rateWindow
+}
+
+// NewWindowedStatsReadWriter returns a new WindowedStats that wraps rw and
+// maintains the given bandwidth for reads and writes independently. It
+// retains the transferred byte counts of the last window seconds. If
+// bandwidth is zero or negative, the WindowedStats will not limit. A window
+// of less than one second is raised to one second.
+func NewWindowedStatsReadWriter(rw io.ReadWriter, bandwidth int, window int) *WindowedStats {
+	if window < 1 {
+		window = 1
+	}
+	return &WindowedStats{
+		r:     NewReader(rw, bandwidth),
+		w:     NewWriter(rw, bandwidth),
+		read:  rateWindow{slots: make([]rateSlot, window)},
+		write: rateWindow{slots: make([]rateSlot, window)},
+	}
+}
+
+// Read implements the io.Reader interface and maintains the given bandwidth.
+func (ws *WindowedStats) Read(p []byte) (n int, err error) {
+	n, err = ws.r.Read(p)
+	ws.read.add(time.Now(), n)
+	return n, err
+}
+
+// Write implements the io.Writer interface and maintains the given
+// bandwidth.
+func (ws *WindowedStats) Write(p []byte) (n int, err error) {
+	n, err = ws.w.Write(p)
+	ws.write.add(time.Now(), n)
+	return n, err
+}
+
+// ReadRate returns the average read rate in bytes per second over the last
+// seconds, including the current one. last is clamped to the window.
+func (ws *WindowedStats) ReadRate(last int) float64 {
+	return ws.read.rate(time.Now(), last)
+}
+
+// WriteRate returns the average write rate in bytes per second over the last
+// seconds, including the current one. last is clamped to the window.
+func (ws *WindowedStats) WriteRate(last int) float64 {
+	return ws.write.rate(time.Now(), last)
+}
+
+// rateSlot counts the bytes transferred within one second.
+type rateSlot struct {
+	sec int64 // unix seconds
+	n   int64
+}
+
+// rateWindow is a circular buffer of per second byte counts.
+type rateWindow struct {
+	mu    sync.Mutex
+	slots []rateSlot
+}
+
+func (rw *rateWindow) add(now time.Time, n int) {
+	if n <= 0 {
+		return
+	}
+
+	sec := now.Unix()
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	slot := &rw.slots[sec%int64(len(rw.slots))]
+	if slot.sec != sec {
+		*slot = rateSlot{sec: sec}
+	}
+	slot.n += int64(n)
+}
+
+func (rw *rateWindow) rate(now time.Time, last int) float64 {
+	if last < 1 {
+		last = 1
+	}
+	if last > len(rw.slots) {
+		last = len(rw.slots)
+	}
+
+	sec := now.Unix()
+
+	rw.mu.Lock()
+	defer rw.mu.Unlock()
+
+	var sum int64
+	for _, slot := range rw.slots {
+		if slot.sec > sec-int64(last) && slot.sec <= sec {
+			sum += slot.n
+		}
+	}
+
+	return float64(sum) / float64(last)
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021 Johannes Kohnen <jwkohnen-github@ko-sys.com>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package bwio
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+	"time"
+)
+
+func TestRateWindow(t *testing.T) {
+	t.Parallel()
+
+	rw := rateWindow{slots: make([]rateSlot, 3)}
+	t0 := time.Unix(1000, 0)
+
+	rw.add(t0, 300)
+	rw.add(t0.Add(500*time.Millisecond), 300)
+	rw.add(t0.Add(time.Second), 600)
+	rw.add(t0.Add(2*time.Second), 300)
+
+	now := t0.Add(2 * time.Second)
+	testt := []struct {
+		last int
+		want float64
+	}{
+		{1, 300},
+		{2, 450},
+		{3, 500},
+		{4, 500}, // clamped to the window
+		{0, 300}, // clamped to one second
+	}
+	for _, testc := range testt {
+		if got := rw.rate(now, testc.last); got != testc.want {
+			t.Errorf("rate(%d): want %f, got %f.", testc.last, testc.want, got)
+		}
+	}
+
+	// The slot of t0 gets overwritten after three seconds.
+	rw.add(t0.Add(3*time.Second), 900)
+	if got := rw.rate(t0.Add(3*time.Second), 3); got != 600 {
+		t.Errorf("Want 600 after wrap around, got %f.", got)
+	}
+}
+
+func TestWindowedStats(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 1000))
+	ws := NewWindowedStatsReadWriter(&buf, 0, 5)
+
+	if _, err := io.Copy(ioutil.Discard, ws); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ws.Write(make([]byte, 500)); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := ws.ReadRate(2); got != 500 {
+		t.Errorf("Want read rate 500, got %f.", got)
+	}
+	if got := ws.WriteRate(2); got != 250 {
+		t.Errorf("Want write rate 250, got %f.", got)
+	}
+}